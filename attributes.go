@@ -36,13 +36,16 @@ func (as Attributes) Swap(i, j int) {
 	as[i], as[j] = as[j], as[i]
 }
 
+// Less orders attributes by namespace, key and value.
 func (as Attributes) Less(i, j int) bool {
 	ai, aj := &as[i], &as[j]
-	return ai.Namespace < aj.Namespace ||
-		ai.Namespace == aj.Namespace &&
-			ai.Key < aj.Key ||
-		ai.Key == aj.Key &&
-			ai.Val < aj.Val
+	if ai.Namespace != aj.Namespace {
+		return ai.Namespace < aj.Namespace
+	}
+	if ai.Key != aj.Key {
+		return ai.Key < aj.Key
+	}
+	return ai.Val < aj.Val
 }
 
 func (as Attributes) index(key, namespace string) int {
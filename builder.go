@@ -1,6 +1,38 @@
 package hck
 
-import "golang.org/x/net/html"
+import (
+	"errors"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// ErrIllegalBuilder is reported when a builder without nodes is used.
+	ErrIllegalBuilder = errors.New("illegal builder")
+
+	// ErrNoParent is reported when the root node is left.
+	ErrNoParent = errors.New("no nodes to leave")
+
+	// ErrVoidElement is reported by checked builders when children are added to a void element like <img>.
+	ErrVoidElement = errors.New("void elements can not have children")
+)
+
+// BuildError records the first error of a checked Builder.
+type BuildError struct {
+	// Op is the name of the failing Builder method
+	Op string
+	// Path of the builder when the error occurred
+	Path Path
+	Err  error
+}
+
+func (e *BuildError) Error() string {
+	return "builder " + e.Op + " at " + e.Path.String() + ": " + e.Err.Error()
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
 
 type Builder interface {
 	// set namespace on current tag
@@ -29,10 +61,26 @@ type Builder interface {
 
 	// retrieve the root node
 	Root() *Node
+
+	// retrieve the first error of a checked builder
+	Err() error
+
+	// retrieve the root node and the first error of a checked builder
+	Done() (*Node, error)
 }
 
 type builder struct {
 	path Path
+
+	// shared by all builders derived from a checked builder, nil otherwise
+	state *buildState
+}
+
+type buildState struct {
+	err error
+
+	// validate the tree in Done
+	validate bool
 }
 
 var _ Builder = builder{}
@@ -41,6 +89,73 @@ func Build(n *Node) Builder {
 	return builder{path: Path{n}}
 }
 
+// Checked retrieves a builder which records the first error instead of panicking.
+// After an error, all modifications are ignored.
+// The error is shared with all builders derived from the checked builder
+// and can be retrieved with Err or Done.
+func Checked(b Builder) Builder {
+	bb, ok := b.(builder)
+	if !ok {
+		return b
+	}
+	if bb.state == nil {
+		bb.state = &buildState{}
+	}
+	return bb
+}
+
+// Validated retrieves a checked builder which also validates the tree in Done.
+// Content model violations are reported as *ValidationError,
+// the root node is still retrieved with them.
+func Validated(b Builder) Builder {
+	bb, ok := Checked(b).(builder)
+	if !ok {
+		return b
+	}
+	bb.state.validate = true
+	return bb
+}
+
+// check reports whether the builder can be modified.
+// It records or panics with ErrIllegalBuilder if the builder has no node.
+func (b builder) check(op string) bool {
+	if b.state != nil && b.state.err != nil {
+		return false
+	}
+	if b.path.Node() == nil {
+		b.fail(op, ErrIllegalBuilder)
+		return false
+	}
+	return true
+}
+
+// checkParent is like check but checked builders also report
+// whether children can be added. Unchecked builders add children to void elements.
+func (b builder) checkParent(op string) bool {
+	if !b.check(op) {
+		return false
+	}
+	if b.state != nil && b.Node().IsVoid() {
+		b.fail(op, ErrVoidElement)
+		return false
+	}
+	return true
+}
+
+// fail records an error in checked builders and panics in unchecked ones.
+func (b builder) fail(op string, err error) {
+	if b.state == nil {
+		panic(err)
+	}
+	if b.state.err == nil {
+		b.state.err = &BuildError{
+			Op:   op,
+			Path: append(Path{}, b.path...),
+			Err:  err,
+		}
+	}
+}
+
 func Document(children ...*Node) *Node {
 	return &Node{
 		Type:     html.DocumentNode,
@@ -67,7 +182,9 @@ func Tag(tag string) Builder {
 }
 
 func (b builder) Namespace(ns string) Builder {
-	b.Node().Namespace = ns
+	if b.check("Namespace") {
+		b.Node().Namespace = ns
+	}
 	return b
 }
 
@@ -76,8 +193,8 @@ func (b builder) Attr(key, value string) Builder {
 }
 
 func (b builder) AttrNS(key, namespace, value string) Builder {
-	if len(b.path) == 0 {
-		panic("illegal builder")
+	if !b.check("AttrNS") {
+		return b
 	}
 	n := b.Node()
 	n.Attributes = append(n.Attributes, html.Attribute{
@@ -89,8 +206,8 @@ func (b builder) AttrNS(key, namespace, value string) Builder {
 }
 
 func (b builder) Text(text string) Builder {
-	if len(b.path) == 0 {
-		panic("illegal builder")
+	if !b.checkParent("Text") {
+		return b
 	}
 	n := b.Node()
 	n.Children = append(n.Children, Text(text))
@@ -98,20 +215,22 @@ func (b builder) Text(text string) Builder {
 }
 
 func (b builder) Tag(tag string) Builder {
-	if len(b.path) == 0 {
-		panic("illegal builder")
+	if !b.checkParent("Tag") {
+		return b
 	}
 	c := Tag(tag).Node()
 	n := b.Node()
 	n.Children = append(n.Children, c)
+	depth := len(b.path)
 	return builder{
-		path: append(b.path, c),
+		path:  append(b.path[:depth:depth], c),
+		state: b.state,
 	}
 }
 
 func (b builder) Children(children ...*Node) Builder {
-	if len(b.path) == 0 {
-		panic("illegal builder")
+	if !b.checkParent("Children") {
+		return b
 	}
 	n := b.Node()
 	n.Children = append(n.Children, children...)
@@ -119,12 +238,17 @@ func (b builder) Children(children ...*Node) Builder {
 }
 
 func (b builder) Leave() Builder {
+	if b.state != nil && b.state.err != nil {
+		return b
+	}
 	depth := len(b.path) - 1
 	if depth <= 0 {
-		panic("no nodes to leave")
+		b.fail("Leave", ErrNoParent)
+		return b
 	}
 	return builder{
-		path: b.path[:depth],
+		path:  b.path[:depth],
+		state: b.state,
 	}
 }
 
@@ -133,8 +257,32 @@ func (b builder) Node() *Node {
 }
 
 func (b builder) Root() *Node {
-	if n := b.path[0]; n != nil {
-		return n
+	if len(b.path) > 0 && b.path[0] != nil {
+		return b.path[0]
+	}
+	b.fail("Root", ErrIllegalBuilder)
+	return nil
+}
+
+func (b builder) Err() error {
+	if b.state == nil {
+		return nil
+	}
+	return b.state.err
+}
+
+func (b builder) Done() (*Node, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	root := b.Root()
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	if b.state != nil && b.state.validate {
+		if vs := root.Validate(); len(vs) > 0 {
+			return root, &ValidationError{Violations: vs}
+		}
 	}
-	panic("illegal builder")
+	return root, nil
 }
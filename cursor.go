@@ -1,5 +1,12 @@
 package hck
 
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
 func (n *Node) Cursor() *Cursor {
 	if n == nil {
 		return nil
@@ -27,17 +34,56 @@ func (p Path) Node() *Node {
 	return nil
 }
 
+// String describes the path by the tags of its nodes, e.g. "html > body > #text".
+func (p Path) String() string {
+	var b strings.Builder
+	for i, n := range p {
+		if i > 0 {
+			b.WriteString(" > ")
+		}
+		switch {
+		case n == nil:
+			b.WriteString("<nil>")
+		case n.Type == html.ElementNode:
+			if n.Namespace != "" {
+				b.WriteString(n.Namespace + ":")
+			}
+			b.WriteString(n.Data)
+		default:
+			b.WriteString(nodeTypeNames[n.Type])
+		}
+	}
+	return b.String()
+}
+
+var nodeTypeNames = map[html.NodeType]string{
+	html.ErrorNode:    "#error",
+	html.TextNode:     "#text",
+	html.DocumentNode: "#document",
+	html.CommentNode:  "#comment",
+	html.DoctypeNode:  "#doctype",
+	html.RawNode:      "#raw",
+}
+
 func (p Path) Cursor() *Cursor {
 	if len(p) == 0 {
 		return nil
 	}
 	idx := -1
-	if pi := len(p) - 2; pi >= 0 {
-		idx = p[pi].Children.Index(p[pi+1])
+	var up []int
+	for i := 1; i < len(p); i++ {
+		if i > 1 {
+			up = append(up, idx)
+		}
+		idx = -1
+		if p[i-1] != nil {
+			idx = p[i-1].Children.Index(p[i])
+		}
 	}
 	return &Cursor{
 		path: p,
 		idx:  idx,
+		up:   up,
 	}
 }
 
@@ -51,12 +97,20 @@ func (p Path) Prev() Path {
 // Cursor points to a node and stores the path to it.
 // It simplifies navigation on nodes.
 //
-// You must not modify the path to a cursor and the sibling nodes.
+// You must not modify the path to a cursor and the sibling nodes
+// except through the cursor itself.
+// Modifications through one cursor may invalidate other cursors on the same tree.
 type Cursor struct {
 	path Path
 
 	// index of current node in its parent's children
 	idx int
+
+	// indexes of the ancestors below the root in their parent's children
+	up []int
+
+	// depth of the node the cursor can not move above or beside
+	top int
 }
 
 // Cursor creates a new cursor pointing to the same node.
@@ -64,7 +118,67 @@ func (c *Cursor) Cursor() *Cursor {
 	return &Cursor{
 		path: append(Path{}, c.path...),
 		idx:  c.idx,
+		up:   append([]int(nil), c.up...),
+		top:  c.top,
+	}
+}
+
+// sub creates a cursor on the current node which is restricted to its subtree.
+// The ancestors stay available in its path.
+func (c *Cursor) sub() *Cursor {
+	return &Cursor{
+		path: c.Path(),
+		idx:  c.idx,
+		up:   append([]int(nil), c.up...),
+		top:  c.Depth(),
+	}
+}
+
+// parent retrieves a cursor on the parent node sharing the path of c.
+// It is only used for matching and must not be moved.
+// If no parent exists, nil is returned.
+func (c *Cursor) parent() *Cursor {
+	d := len(c.path) - 1
+	if d <= 0 {
+		return nil
+	}
+	idx := -1
+	var up []int
+	if d > 1 {
+		idx = c.up[d-2]
+		up = c.up[: d-2 : d-2]
 	}
+	return &Cursor{
+		path: c.path[:d:d],
+		idx:  idx,
+		up:   up,
+	}
+}
+
+// siblings retrieves the children of the parent node.
+// If no parent exists, nil is returned.
+func (c *Cursor) siblings() Siblings {
+	if d := len(c.path) - 1; d > 0 {
+		return c.path[d-1].Children
+	}
+	return nil
+}
+
+// index retrieves the index of the node at depth d of the path in its parent's children.
+func (c *Cursor) index(d int) int {
+	if d == len(c.path)-1 {
+		return c.idx
+	}
+	return c.up[d-1]
+}
+
+// Index retrieves the index of the current node in its parent's children.
+// If no parent exists, it returns -1.
+func (c *Cursor) Index() int {
+	if len(c.path) <= 1 {
+		return -1
+	}
+	return c.idx
 }
 
 // Depth retrieves the number of ancestor nodes.
@@ -96,13 +210,29 @@ func (c *Cursor) Swap(n *Node) (prev *Node, ok bool) {
 	return cn.Swap(n), true
 }
 
+// Seek moves to the depth-first next node matching m.
+// If no match is found, it returns false.
 func (c *Cursor) Seek(m Matcher) bool {
 	for {
 		n := c.Next()
 		if n == nil {
 			return false
 		}
-		if m.Match(n) {
+		if Matches(m, c) {
+			return true
+		}
+	}
+}
+
+// SeekPrev moves to the depth-first previous node matching m.
+// If no match is found, it returns false.
+func (c *Cursor) SeekPrev(m Matcher) bool {
+	for {
+		n := c.Prev()
+		if n == nil {
+			return false
+		}
+		if Matches(m, c) {
 			return true
 		}
 	}
@@ -111,7 +241,7 @@ func (c *Cursor) Seek(m Matcher) bool {
 // PrevSibling moves to and retrieves the previous sibling node.
 // If no previous sibling exists, the cursor does not move and nil is returned.
 func (c *Cursor) PrevSibling() *Node {
-	if c.idx <= 0 || len(c.path) <= 1 {
+	if c.idx <= 0 || len(c.path)-1 <= c.top {
 		return nil
 	}
 	c.idx--
@@ -123,7 +253,7 @@ func (c *Cursor) PrevSibling() *Node {
 // NextSibling moves to and retrieves the next sibling node.
 // If no next sibling exists, the cursor does not move and nil is returned.
 func (c *Cursor) NextSibling() *Node {
-	if len(c.path) <= 1 {
+	if len(c.path)-1 <= c.top {
 		return nil
 	}
 	pi := len(c.path) - 2
@@ -140,14 +270,15 @@ func (c *Cursor) NextSibling() *Node {
 // Parent moves to and retrieves the parent node.
 // If no parent exists, the cursor does not move and nil is returned.
 func (c *Cursor) Parent() *Node {
-	if len(c.path) == 1 {
+	if len(c.path)-1 <= c.top {
 		return nil
 	}
 	pi := len(c.path) - 2
 	p := c.path[pi]
 	c.path = c.path[:pi+1]
 	if pi > 0 {
-		c.idx = c.path[pi-1].Children.Index(p)
+		c.idx = c.up[pi-1]
+		c.up = c.up[:pi-1]
 	} else {
 		c.idx = 0
 	}
@@ -161,8 +292,7 @@ func (c *Cursor) FirstChild() *Node {
 	if len(n.Children) == 0 {
 		return nil
 	}
-	c.idx = 0
-	c.path = append(c.path, n.Children[c.idx])
+	c.descend(0)
 	return c.Node()
 }
 
@@ -173,18 +303,29 @@ func (c *Cursor) LastChild() *Node {
 	if len(n.Children) == 0 {
 		return nil
 	}
-	c.idx = len(n.Children) - 1
-	c.path = append(c.path, n.Children[c.idx])
+	c.descend(len(n.Children) - 1)
 	return c.Node()
 }
 
+// descend moves to the child i of the current node.
+func (c *Cursor) descend(i int) {
+	if len(c.path) > 1 {
+		c.up = append(c.up, c.idx)
+	}
+	c.idx = i
+	c.path = append(c.path, c.path[len(c.path)-1].Children[i])
+}
+
 // Prev moves to and retrieves the depth-first previous node.
+// It is the inverse of Next: the previous sibling's last descendant or the parent.
 // If no previous node exists, the cursor does not move and nil is returned.
 func (c *Cursor) Prev() *Node {
-	if p := c.PrevSibling(); p != nil {
-		return p
+	if c.PrevSibling() == nil {
+		return c.Parent()
+	}
+	for c.LastChild() != nil {
 	}
-	return c.Parent()
+	return c.Node()
 }
 
 // Next moves to and retrieves the depth-first next node.
@@ -207,3 +348,156 @@ func (c *Cursor) Next() *Node {
 		}
 	}
 }
+
+// replaceSiblings replaces del nodes of the parent's children starting at i with ns.
+// It returns false if the current node has no parent the cursor may modify.
+func (c *Cursor) replaceSiblings(i, del int, ns ...*Node) bool {
+	d := len(c.path) - 1
+	if d <= c.top {
+		return false
+	}
+	p := c.path[d-1]
+	p.Children = p.Children.Splice(i, del, ns...)
+	return true
+}
+
+// InsertBefore inserts nodes as previous siblings of the current node.
+// The cursor does not move.
+// If the current node has no parent, nothing is inserted and false is returned.
+func (c *Cursor) InsertBefore(ns ...*Node) bool {
+	if !c.replaceSiblings(c.idx, 0, ns...) {
+		return false
+	}
+	c.idx += len(ns)
+	return true
+}
+
+// InsertAfter inserts nodes as next siblings of the current node.
+// The cursor does not move.
+// If the current node has no parent, nothing is inserted and false is returned.
+func (c *Cursor) InsertAfter(ns ...*Node) bool {
+	return c.replaceSiblings(c.idx+1, 0, ns...)
+}
+
+// AppendChild adds nodes after the last child of the current node.
+// The cursor does not move.
+func (c *Cursor) AppendChild(ns ...*Node) {
+	n := c.Node()
+	n.Children = n.Children.Splice(len(n.Children), 0, ns...)
+}
+
+// PrependChild adds nodes before the first child of the current node.
+// The cursor does not move.
+func (c *Cursor) PrependChild(ns ...*Node) {
+	n := c.Node()
+	n.Children = n.Children.Splice(0, 0, ns...)
+}
+
+// Remove removes the current node from its parent and retrieves it.
+// The cursor moves to the depth-first previous node, Next continues
+// with the node which followed the removed one.
+// If the current node has no parent, the cursor does not move and nil is returned.
+func (c *Cursor) Remove() *Node {
+	d := len(c.path) - 1
+	if d <= c.top {
+		return nil
+	}
+	n, p, i := c.path[d], c.path[d-1], c.idx
+	c.Prev()
+	p.Children = p.Children.Splice(i, 1)
+	return n
+}
+
+// Replace replaces the current node with n in its parent and retrieves the replaced node.
+// The cursor moves to n.
+// If the current node has no parent, the cursor does not move and nil is returned.
+func (c *Cursor) Replace(n *Node) *Node {
+	prev := c.Node()
+	if !c.replaceSiblings(c.idx, 1, n) {
+		return nil
+	}
+	c.path[len(c.path)-1] = n
+	return prev
+}
+
+// Wrap replaces the current node with parent and appends it to the children of parent.
+// The cursor does not move, parent is added to its path.
+// If the current node has no parent, nothing is changed and false is returned.
+func (c *Cursor) Wrap(parent *Node) bool {
+	n := c.Node()
+	if !c.replaceSiblings(c.idx, 1, parent) {
+		return false
+	}
+	parent.Children = parent.Children.Splice(len(parent.Children), 0, n)
+	d := len(c.path) - 1
+	path := make(Path, d, d+2)
+	copy(path, c.path)
+	c.path = append(path, parent, n)
+	c.up = append(c.up[:d-1:d-1], c.idx)
+	c.idx = len(parent.Children) - 1
+	return true
+}
+
+// Unwrap replaces the parent of the current node with its children
+// and retrieves the parent without children.
+// The cursor does not move, the parent is removed from its path.
+// If the current node has no grandparent, nothing is changed and nil is returned.
+func (c *Cursor) Unwrap() *Node {
+	d := len(c.path) - 1
+	if d-1 <= c.top {
+		return nil
+	}
+	n, p, gp := c.path[d], c.path[d-1], c.path[d-2]
+	pi := c.up[d-2]
+	gp.Children = gp.Children.Splice(pi, 1, p.Children...)
+	p.Children = nil
+	c.path = append(c.path[:d-1], n)
+	c.up = c.up[:d-2]
+	c.idx += pi
+	return p
+}
+
+// ErrCursorTop is reported when nodes are inserted beside the top node of a cursor.
+var ErrCursorTop = errors.New("can not insert beside the top node of the cursor")
+
+// InsertPosition is the position of inserted nodes relative to the current node.
+type InsertPosition int
+
+const (
+	// BeforeBegin inserts previous siblings.
+	BeforeBegin InsertPosition = iota
+	// AfterBegin inserts first children.
+	AfterBegin
+	// BeforeEnd inserts last children.
+	BeforeEnd
+	// AfterEnd inserts next siblings.
+	AfterEnd
+)
+
+// InsertHTML parses s and inserts the nodes at pos like insertAdjacentHTML in browsers.
+// Siblings are parsed in the context of the parent node, children in the context of the current node.
+// The cursor does not move.
+func (c *Cursor) InsertHTML(pos InsertPosition, s string) error {
+	context := c.Node()
+	if pos == BeforeBegin || pos == AfterEnd {
+		if c.Depth() <= c.top {
+			return ErrCursorTop
+		}
+		context = c.path[c.Depth()-1]
+	}
+	ns, err := ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		return err
+	}
+	switch pos {
+	case BeforeBegin:
+		c.InsertBefore(ns...)
+	case AfterBegin:
+		c.PrependChild(ns...)
+	case BeforeEnd:
+		c.AppendChild(ns...)
+	case AfterEnd:
+		c.InsertAfter(ns...)
+	}
+	return nil
+}
@@ -0,0 +1,506 @@
+package hck
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// EditOp is the kind of change of an Edit.
+type EditOp int
+
+const (
+	// InsertNode inserts NewNode at Path.
+	InsertNode EditOp = iota
+	// DeleteNode deletes OldNode at Path.
+	DeleteNode
+	// MoveNode moves the node at From to Path.
+	// Path is the position after the node was removed at From.
+	MoveNode
+	// ReplaceNode replaces OldNode at Path with NewNode.
+	ReplaceNode
+	// AddAttr adds the attribute Namespace and Key with the value New.
+	AddAttr
+	// SetAttr changes the value of an attribute from Old to New.
+	SetAttr
+	// RemoveAttr removes an attribute with the value Old.
+	RemoveAttr
+	// SetData changes the data of a text, comment or doctype node from Old to New.
+	SetData
+)
+
+var editOpNames = [...]string{
+	InsertNode:  "insert",
+	DeleteNode:  "delete",
+	MoveNode:    "move",
+	ReplaceNode: "replace",
+	AddAttr:     "add attribute",
+	SetAttr:     "set attribute",
+	RemoveAttr:  "remove attribute",
+	SetData:     "set data",
+}
+
+func (op EditOp) String() string {
+	if op < 0 || int(op) >= len(editOpNames) {
+		return "EditOp(" + strconv.Itoa(int(op)) + ")"
+	}
+	return editOpNames[op]
+}
+
+// IndexPath is the position of a node as the indexes of the children
+// leading to it from the root. The root has an empty IndexPath.
+type IndexPath []int
+
+func (p IndexPath) String() string {
+	if len(p) == 0 {
+		return "/"
+	}
+	var b strings.Builder
+	for _, i := range p {
+		b.WriteByte('/')
+		b.WriteString(strconv.Itoa(i))
+	}
+	return b.String()
+}
+
+// child retrieves a new path to the child at index i.
+func (p IndexPath) child(i int) IndexPath {
+	return append(p[:len(p):len(p)], i)
+}
+
+// Edit is a change of a tree.
+// Its paths refer to the tree after all previous edits were applied.
+type Edit struct {
+	Op EditOp
+
+	// Path of the changed node
+	Path IndexPath
+
+	// From is the previous position of a moved node
+	From IndexPath
+
+	// Namespace and Key of a changed attribute
+	Namespace string
+	Key       string
+
+	// Old and New value of a changed attribute or node data
+	Old string
+	New string
+
+	// OldNode is the changed node in the original tree, NewNode the node in the changed tree.
+	// Inserted nodes have no OldNode, deleted nodes no NewNode.
+	OldNode *Node
+	NewNode *Node
+}
+
+func (e Edit) String() string {
+	var b strings.Builder
+	b.WriteString(e.Op.String())
+	b.WriteByte(' ')
+	b.WriteString(e.Path.String())
+	switch e.Op {
+	case InsertNode:
+		b.WriteString(" " + describe(e.NewNode))
+	case DeleteNode:
+		b.WriteString(" " + describe(e.OldNode))
+	case MoveNode:
+		b.WriteString(" from " + e.From.String() + " " + describe(e.OldNode))
+	case ReplaceNode:
+		b.WriteString(" " + describe(e.OldNode) + " with " + describe(e.NewNode))
+	case AddAttr:
+		b.WriteString(" " + describe(e.OldNode) + " " + attrName(e.Namespace, e.Key) + "=" + strconv.Quote(e.New))
+	case SetAttr:
+		b.WriteString(" " + describe(e.OldNode) + " " + attrName(e.Namespace, e.Key) + ": " +
+			strconv.Quote(e.Old) + " -> " + strconv.Quote(e.New))
+	case RemoveAttr:
+		b.WriteString(" " + describe(e.OldNode) + " " + attrName(e.Namespace, e.Key) + "=" + strconv.Quote(e.Old))
+	case SetData:
+		b.WriteString(" " + strconv.Quote(e.Old) + " -> " + strconv.Quote(e.New))
+	}
+	return b.String()
+}
+
+// Edits change a tree into another one.
+type Edits []Edit
+
+// String retrieves a report with one edit per line.
+func (es Edits) String() string {
+	var b strings.Builder
+	for _, e := range es {
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func attrName(namespace, key string) string {
+	if namespace == "" {
+		return key
+	}
+	return namespace + ":" + key
+}
+
+// describe retrieves a short description of a node for reports.
+func describe(n *Node) string {
+	if n == nil {
+		return "<nil>"
+	}
+	switch n.Type {
+	case html.ElementNode:
+		s := "<" + attrName(n.Namespace, n.Data)
+		if id := n.ID(); id != "" {
+			s += " id=" + strconv.Quote(id)
+		}
+		return s + ">"
+	case html.TextNode, html.CommentNode:
+		d := n.Data
+		if len(d) > 40 {
+			d = d[:37] + "..."
+		}
+		return nodeTypeNames[n.Type] + " " + strconv.Quote(d)
+	}
+	return nodeTypeNames[n.Type]
+}
+
+// Diff retrieves the edits changing the tree a into b.
+//
+// Children are matched by the longest common subsequence of similar nodes:
+// elements with the same tag and id, or nodes of the same other type.
+// Equal subtrees count twice, so unchanged nodes are preferred to changed ones.
+// Long lists of changed siblings are matched greedily by equal subtrees instead.
+// Elements with an id are moved among their siblings instead of deleted and inserted.
+// The nodes of a and b are not modified, Edits refer to them.
+func Diff(a, b *Node) Edits {
+	d := differ{subtrees: subtrees{}}
+	d.diff(IndexPath{}, a, b)
+	return d.edits
+}
+
+type differ struct {
+	edits    Edits
+	subtrees subtrees
+}
+
+func (d *differ) add(e Edit) {
+	d.edits = append(d.edits, e)
+}
+
+// similar reports whether a and b can be changed into each other
+// without replacing them.
+func similar(a, b *Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Type != b.Type {
+		return false
+	}
+	if a.Type != html.ElementNode {
+		return true
+	}
+	return a.Data == b.Data && a.Namespace == b.Namespace && a.ID() == b.ID()
+}
+
+func (d *differ) diff(p IndexPath, a, b *Node) {
+	if a == b {
+		return
+	}
+	if !similar(a, b) {
+		d.add(Edit{Op: ReplaceNode, Path: p, OldNode: a, NewNode: b})
+		return
+	}
+	if a.Data != b.Data {
+		d.add(Edit{Op: SetData, Path: p, Old: a.Data, New: b.Data, OldNode: a, NewNode: b})
+	}
+	d.attributes(p, a, b)
+	d.children(p, a, b)
+}
+
+func (d *differ) attributes(p IndexPath, a, b *Node) {
+	for _, aa := range a.Attributes {
+		if b.Attribute(aa.Key, aa.Namespace) == nil {
+			d.add(Edit{Op: RemoveAttr, Path: p, Namespace: aa.Namespace, Key: aa.Key,
+				Old: aa.Val, OldNode: a, NewNode: b})
+		}
+	}
+	for _, ba := range b.Attributes {
+		switch aa := a.Attribute(ba.Key, ba.Namespace); {
+		case aa == nil:
+			d.add(Edit{Op: AddAttr, Path: p, Namespace: ba.Namespace, Key: ba.Key,
+				New: ba.Val, OldNode: a, NewNode: b})
+		case aa.Val != ba.Val:
+			d.add(Edit{Op: SetAttr, Path: p, Namespace: ba.Namespace, Key: ba.Key,
+				Old: aa.Val, New: ba.Val, OldNode: a, NewNode: b})
+		}
+	}
+}
+
+func (d *differ) children(p IndexPath, a, b *Node) {
+	as, bs := a.Children, b.Children
+	partner := matchSiblings(d.subtrees, as, bs)
+	// from[j] is the index in as of the match of bs[j] or -1
+	from := make([]int, len(bs))
+	for j := range from {
+		from[j] = -1
+	}
+	// delete from the end to keep the indexes of earlier nodes
+	for i := len(as) - 1; i >= 0; i-- {
+		if partner[i] < 0 {
+			d.add(Edit{Op: DeleteNode, Path: p.child(i), OldNode: as[i]})
+		} else {
+			from[partner[i]] = i
+		}
+	}
+	// cur contains the indexes in bs of the current children
+	cur := make([]int, 0, len(bs))
+	for _, j := range partner {
+		if j >= 0 {
+			cur = append(cur, j)
+		}
+	}
+	for j, bn := range bs {
+		if from[j] < 0 {
+			d.add(Edit{Op: InsertNode, Path: p.child(j), NewNode: bn})
+			cur = append(cur[:j], append([]int{j}, cur[j:]...)...)
+			continue
+		}
+		k := j
+		for cur[k] != j {
+			k++
+		}
+		if k != j {
+			d.add(Edit{Op: MoveNode, Path: p.child(j), From: p.child(k), OldNode: as[from[j]], NewNode: bn})
+			copy(cur[j+1:k+1], cur[j:k])
+			cur[j] = j
+		}
+	}
+	for j, bn := range bs {
+		if i := from[j]; i >= 0 {
+			d.diff(p.child(j), as[i], bn)
+		}
+	}
+}
+
+// maxSiblingPairs limits the pairs of siblings compared by matchSiblings.
+// Longer lists of changed siblings are matched greedily.
+const maxSiblingPairs = 1 << 20
+
+// matchSiblings retrieves the index of the match in bs for each node in as or -1.
+// Equal subtrees at the start and end are matched first, then the longest common
+// subsequence of the other similar nodes, where equal subtrees count twice.
+// If there are more than maxSiblingPairs pairs, matchGreedy is used instead.
+// Remaining elements with an id are matched to the element with the same id.
+// Subtrees are compared by their hashes in st.
+func matchSiblings(st subtrees, as, bs Siblings) []int {
+	partner := make([]int, len(as))
+	for i := range partner {
+		partner[i] = -1
+	}
+	pre, suf := 0, 0
+	for pre < len(as) && pre < len(bs) && st.same(as[pre], bs[pre]) {
+		partner[pre] = pre
+		pre++
+	}
+	for suf < len(as)-pre && suf < len(bs)-pre && st.same(as[len(as)-1-suf], bs[len(bs)-1-suf]) {
+		partner[len(as)-1-suf] = len(bs) - 1 - suf
+		suf++
+	}
+	mid := partner[pre : len(as)-suf]
+	if n, m := len(mid), len(bs)-pre-suf; n*m <= maxSiblingPairs {
+		matchSubsequence(st, mid, as[pre:len(as)-suf], bs[pre:len(bs)-suf])
+	} else {
+		matchGreedy(st, mid, as[pre:len(as)-suf], bs[pre:len(bs)-suf])
+	}
+	for i := range mid {
+		if mid[i] >= 0 {
+			mid[i] += pre
+		}
+	}
+	taken := make([]bool, len(bs))
+	for _, j := range partner {
+		if j >= 0 {
+			taken[j] = true
+		}
+	}
+	// untaken elements by id
+	ids := map[string][]int{}
+	for j, bn := range bs {
+		if !taken[j] && isElement(bn) && bn.ID() != "" {
+			ids[bn.ID()] = append(ids[bn.ID()], j)
+		}
+	}
+	for i, an := range as {
+		if partner[i] >= 0 || !isElement(an) || an.ID() == "" {
+			continue
+		}
+		for _, j := range ids[an.ID()] {
+			if !taken[j] && similar(an, bs[j]) {
+				partner[i] = j
+				taken[j] = true
+				break
+			}
+		}
+	}
+	return partner
+}
+
+// matchSubsequence sets partner[i] to the index of the match of as[i] in bs
+// by the heaviest common subsequence of similar nodes, equal subtrees count twice.
+func matchSubsequence(st subtrees, partner []int, as, bs Siblings) {
+	// weight of a match, equal subtrees are preferred
+	weight := func(i, j int) int {
+		switch {
+		case !similar(as[i], bs[j]):
+			return 0
+		case st.hash(as[i]) == st.hash(bs[j]):
+			return 2
+		}
+		return 1
+	}
+	// lcs[i*w+j] is the weight of the heaviest common subsequence of as[i:] and bs[j:]
+	w := len(bs) + 1
+	lcs := make([]int, (len(as)+1)*w)
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			k := i*w + j
+			lcs[k] = max(lcs[k+w], lcs[k+1])
+			if x := weight(i, j); x > 0 {
+				lcs[k] = max(lcs[k], lcs[k+w+1]+x)
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(as) && j < len(bs); {
+		k := i*w + j
+		switch x := weight(i, j); {
+		case x > 0 && lcs[k] == lcs[k+w+1]+x:
+			partner[i] = j
+			i++
+			j++
+		case lcs[k+w] >= lcs[k+1]:
+			i++
+		default:
+			j++
+		}
+	}
+}
+
+// matchGreedy sets partner[i] to the index of the match of as[i] in bs in O(n log n).
+// Subtrees that are unique in as and bs are matched if they keep their order,
+// the siblings between them are matched from both ends as long as they are equal
+// and then in order as long as they are similar.
+func matchGreedy(st subtrees, partner []int, as, bs Siblings) {
+	// position in bs of unique hashes, -1 for repeated ones
+	pos := make(map[uint64]int, len(bs))
+	for j, bn := range bs {
+		if _, ok := pos[st.hash(bn)]; ok {
+			pos[st.hash(bn)] = -1
+		} else {
+			pos[st.hash(bn)] = j
+		}
+	}
+	count := make(map[uint64]int, len(as))
+	for _, an := range as {
+		count[st.hash(an)]++
+	}
+	// longest increasing subsequence of the positions of unique nodes
+	var anchors, tails []int // indices in as
+	prev := make([]int, len(as))
+	for i, an := range as {
+		j, ok := pos[st.hash(an)]
+		if !ok || j < 0 || count[st.hash(an)] != 1 || !similar(an, bs[j]) {
+			continue
+		}
+		k := sort.Search(len(tails), func(k int) bool { return pos[st.hash(as[tails[k]])] >= j })
+		prev[i] = -1
+		if k > 0 {
+			prev[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+			anchors = append(anchors, i)
+		}
+	}
+	// fill the gaps between anchors, anchors is in reverse order
+	i0, j0 := 0, 0
+	for k := len(anchors); k >= 0; k-- {
+		i1, j1 := len(as), len(bs)
+		if k > 0 {
+			i1 = anchors[k-1]
+			j1 = pos[st.hash(as[i1])]
+			partner[i1] = j1
+		}
+		// equal subtrees at both ends first, then similar nodes in order
+		i, j := i0, j0
+		for ; i < i1 && j < j1 && st.same(as[i], bs[j]); i, j = i+1, j+1 {
+			partner[i] = j
+		}
+		ie, je := i1, j1
+		for ; ie > i && je > j && st.same(as[ie-1], bs[je-1]); ie, je = ie-1, je-1 {
+			partner[ie-1] = je - 1
+		}
+		for ; i < ie && j < je && similar(as[i], bs[j]); i, j = i+1, j+1 {
+			partner[i] = j
+		}
+		i0, j0 = i1+1, j1+1
+	}
+}
+
+// subtrees memoizes the hashes of subtrees while matching siblings.
+// Equal subtrees have the same hash, the order of attributes is ignored.
+type subtrees map[*Node]uint64
+
+// hash retrieves the hash of n and its descendants.
+func (st subtrees) hash(n *Node) uint64 {
+	if n == nil {
+		return 0
+	}
+	if h, ok := st[n]; ok {
+		return h
+	}
+	h := fnvUint(fnvOffset, uint64(n.Type))
+	h = fnvString(fnvString(h, n.Namespace), n.Data)
+	var attrs uint64
+	for _, a := range n.Attributes {
+		attrs += fnvString(fnvString(fnvString(fnvOffset, a.Namespace), a.Key), a.Val)
+	}
+	h = fnvUint(h, attrs)
+	for _, c := range n.Children {
+		h = fnvUint(h, st.hash(c))
+	}
+	st[n] = h
+	return h
+}
+
+// parameters of the 64 bit FNV-1a hash
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// same reports whether a and b are similar and have equal subtrees.
+func (st subtrees) same(a, b *Node) bool {
+	return similar(a, b) && st.hash(a) == st.hash(b)
+}
+
+// fnvString adds s and a terminator to the hash h.
+func fnvString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h = (h ^ uint64(s[i])) * fnvPrime
+	}
+	return (h ^ 0xff) * fnvPrime
+}
+
+// fnvUint adds the bytes of v to the hash h.
+func fnvUint(h, v uint64) uint64 {
+	for i := 0; i < 64; i += 8 {
+		h = (h ^ (v >> i & 0xff)) * fnvPrime
+	}
+	return h
+}
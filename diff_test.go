@@ -0,0 +1,116 @@
+package hck_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/arnehormann/hck"
+)
+
+// parse retrieves the document in s.
+func parse(t testing.TB, s string) *hck.Node {
+	t.Helper()
+	doc, err := hck.Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+var diffPairs = []struct {
+	name string
+	a, b string
+}{
+	{"equal", `<p>a</p>`, `<p>a</p>`},
+	{"text", `<p>a</p>`, `<p>b</p>`},
+	{"attributes", `<p class=x title=t>a</p>`, `<p class=y lang=en>a</p>`},
+	{"tag", `<p>x`, `<div>y`},
+	{"insert", `<ul><li>a<li>c</ul>`, `<ul><li>a<li>b<li>c</ul>`},
+	{"delete", `<ul><li>a<li>b<li>c</ul>`, `<ul><li>a<li>c</ul>`},
+	{"ids", `<ul><li id=a>a</li><li id=b>b</li><li id=c>c</li></ul>`,
+		`<ul><li id=c>c!</li><li id=a>a</li><li>new</li><li id=b>b</li></ul>`},
+	{"reordered", `<div><p>1</p><p>2</p><span>3</span></div>`, `<div><span>3</span><p>2</p><p>1</p><!-- c --></div>`},
+	{"table", `<table><tr><td>1<td>2</table>`, `<table><tr><td>2<td>1<tr><td>3</table>`},
+}
+
+func TestDiffPatch(t *testing.T) {
+	for _, tc := range diffPairs {
+		a, b := parse(t, tc.a), parse(t, tc.b)
+		want, orig := render(t, b), render(t, a)
+		es := hck.Diff(a, b)
+		if tc.a == tc.b && len(es) != 0 {
+			t.Errorf("%s: got edits %s for equal trees", tc.name, es)
+		}
+		if render(t, a) != orig || render(t, b) != want {
+			t.Errorf("%s: Diff modified its arguments", tc.name)
+		}
+		n := a.DeepClone()
+		if err := hck.Patch(n, es); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got := render(t, n); got != want {
+			t.Errorf("%s: patched to %s, want %s", tc.name, got, want)
+		}
+		if err := hck.Patch(n, es.Invert()); err != nil {
+			t.Errorf("%s: inverted: %v", tc.name, err)
+		} else if got := render(t, n); got != orig {
+			t.Errorf("%s: inverted to %s, want %s", tc.name, got, orig)
+		}
+	}
+}
+
+// rows creates a tbody with n rows, the cells of the rows at the keys are replaced.
+func rows(n int, cells map[int]string) string {
+	var s strings.Builder
+	s.WriteString("<table><tbody>")
+	for i := 0; i < n; i++ {
+		c, ok := cells[i]
+		if !ok {
+			c = "row " + strconv.Itoa(i)
+		}
+		s.WriteString("<tr><td>" + c + "</td><td>" + strings.Repeat("x", i%5) + "</td></tr>")
+	}
+	s.WriteString("</tbody></table>")
+	return s.String()
+}
+
+func TestDiffLongSiblings(t *testing.T) {
+	const n = 8000
+	for _, tc := range []struct {
+		name  string
+		cells map[int]string
+		edits int
+	}{
+		{"unchanged", nil, 0},
+		{"middle", map[int]string{n / 2: "changed"}, 1},
+		{"first and last", map[int]string{0: "first", n - 1: "last"}, 2},
+		{"spread", map[int]string{0: "a", n / 3: "b", n / 2: "c", n - 1: "d"}, 4},
+	} {
+		a, b := parse(t, rows(n, nil)), parse(t, rows(n, tc.cells))
+		es := hck.Diff(a, b)
+		if len(es) != tc.edits {
+			t.Errorf("%s: got %d edits, want %d", tc.name, len(es), tc.edits)
+		}
+		if err := hck.Patch(a, es); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if render(t, a) != render(t, b) {
+			t.Errorf("%s: patched tree differs", tc.name)
+		}
+	}
+	// a row inserted and one removed far apart
+	a := parse(t, rows(n, nil))
+	b := parse(t, rows(n, nil))
+	body := b.Find(hck.MatchTag("tbody")).All()[0]
+	body.Children = append(body.Children[1:n/2], append(hck.Siblings{tag("tr", tag("td", hck.Text("new")))}, body.Children[n/2:]...)...)
+	es := hck.Diff(a, b)
+	if len(es) > 4 {
+		t.Errorf("inserted and removed: got %d edits", len(es))
+	}
+	if err := hck.Patch(a, es); err != nil {
+		t.Error(err)
+	} else if render(t, a) != render(t, b) {
+		t.Error("inserted and removed: patched tree differs")
+	}
+}
@@ -0,0 +1,23 @@
+// Package el provides typed constructors for HTML elements.
+//
+// Each constructor retrieves a checked hck.Builder inside the new element,
+// it records the first error instead of panicking.
+// Constructors of void elements like Img and Br do not accept children,
+// adding them is reported as hck.ErrVoidElement by Err and Done.
+package el
+
+//go:generate go run gen.go
+
+import (
+	"github.com/arnehormann/hck"
+	"golang.org/x/net/html/atom"
+)
+
+// element creates a checked builder inside a new element with children.
+func element(a atom.Atom, children []*hck.Node) hck.Builder {
+	b := hck.Checked(hck.Tag(a.String()))
+	if len(children) > 0 {
+		b = b.Children(children...)
+	}
+	return b
+}
@@ -0,0 +1,40 @@
+package el_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/arnehormann/hck"
+	"github.com/arnehormann/hck/el"
+)
+
+func TestElements(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		b    hck.Builder
+		html string
+		err  error
+	}{
+		{"div", el.Div(el.P().Text("a").Node()), "<div><p>a</p></div>", nil},
+		{"link", el.A("/x").Text("x"), `<a href="/x">x</a>`, nil},
+		{"img", el.Img("a.png", "a"), `<img src="a.png" alt="a"/>`, nil},
+		{"img text", el.Img("a.png", "a").Text("child"), "", hck.ErrVoidElement},
+		{"br children", el.Br().Children(hck.Text("x")), "", hck.ErrVoidElement},
+	} {
+		n, err := tc.b.Done()
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: got error %v, want %v", tc.name, err, tc.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var s strings.Builder
+		if err := n.Render(&s); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if s.String() != tc.html {
+			t.Errorf("%s: got %s, want %s", tc.name, s.String(), tc.html)
+		}
+	}
+}
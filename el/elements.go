@@ -0,0 +1,573 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+package el
+
+import (
+	"github.com/arnehormann/hck"
+	"golang.org/x/net/html/atom"
+)
+
+// A creates an <a> element with children.
+func A(href string, children ...*hck.Node) hck.Builder {
+	return element(atom.A, children).
+		Attr(atom.Href.String(), href)
+}
+
+// Abbr creates an <abbr> element with children.
+func Abbr(children ...*hck.Node) hck.Builder {
+	return element(atom.Abbr, children)
+}
+
+// Address creates an <address> element with children.
+func Address(children ...*hck.Node) hck.Builder {
+	return element(atom.Address, children)
+}
+
+// Area creates an <area> element, it can not have children.
+func Area() hck.Builder {
+	return element(atom.Area, nil)
+}
+
+// Article creates an <article> element with children.
+func Article(children ...*hck.Node) hck.Builder {
+	return element(atom.Article, children)
+}
+
+// Aside creates an <aside> element with children.
+func Aside(children ...*hck.Node) hck.Builder {
+	return element(atom.Aside, children)
+}
+
+// Audio creates an <audio> element with children.
+func Audio(children ...*hck.Node) hck.Builder {
+	return element(atom.Audio, children)
+}
+
+// B creates a <b> element with children.
+func B(children ...*hck.Node) hck.Builder {
+	return element(atom.B, children)
+}
+
+// Base creates a <base> element, it can not have children.
+func Base() hck.Builder {
+	return element(atom.Base, nil)
+}
+
+// Bdi creates a <bdi> element with children.
+func Bdi(children ...*hck.Node) hck.Builder {
+	return element(atom.Bdi, children)
+}
+
+// Bdo creates a <bdo> element with children.
+func Bdo(children ...*hck.Node) hck.Builder {
+	return element(atom.Bdo, children)
+}
+
+// Blockquote creates a <blockquote> element with children.
+func Blockquote(children ...*hck.Node) hck.Builder {
+	return element(atom.Blockquote, children)
+}
+
+// Body creates a <body> element with children.
+func Body(children ...*hck.Node) hck.Builder {
+	return element(atom.Body, children)
+}
+
+// Br creates a <br> element, it can not have children.
+func Br() hck.Builder {
+	return element(atom.Br, nil)
+}
+
+// Button creates a <button> element with children.
+func Button(children ...*hck.Node) hck.Builder {
+	return element(atom.Button, children)
+}
+
+// Canvas creates a <canvas> element with children.
+func Canvas(children ...*hck.Node) hck.Builder {
+	return element(atom.Canvas, children)
+}
+
+// Caption creates a <caption> element with children.
+func Caption(children ...*hck.Node) hck.Builder {
+	return element(atom.Caption, children)
+}
+
+// Cite creates a <cite> element with children.
+func Cite(children ...*hck.Node) hck.Builder {
+	return element(atom.Cite, children)
+}
+
+// Code creates a <code> element with children.
+func Code(children ...*hck.Node) hck.Builder {
+	return element(atom.Code, children)
+}
+
+// Col creates a <col> element, it can not have children.
+func Col() hck.Builder {
+	return element(atom.Col, nil)
+}
+
+// Colgroup creates a <colgroup> element with children.
+func Colgroup(children ...*hck.Node) hck.Builder {
+	return element(atom.Colgroup, children)
+}
+
+// Data creates a <data> element with children.
+func Data(children ...*hck.Node) hck.Builder {
+	return element(atom.Data, children)
+}
+
+// Datalist creates a <datalist> element with children.
+func Datalist(children ...*hck.Node) hck.Builder {
+	return element(atom.Datalist, children)
+}
+
+// Dd creates a <dd> element with children.
+func Dd(children ...*hck.Node) hck.Builder {
+	return element(atom.Dd, children)
+}
+
+// Del creates a <del> element with children.
+func Del(children ...*hck.Node) hck.Builder {
+	return element(atom.Del, children)
+}
+
+// Details creates a <details> element with children.
+func Details(children ...*hck.Node) hck.Builder {
+	return element(atom.Details, children)
+}
+
+// Dfn creates a <dfn> element with children.
+func Dfn(children ...*hck.Node) hck.Builder {
+	return element(atom.Dfn, children)
+}
+
+// Dialog creates a <dialog> element with children.
+func Dialog(children ...*hck.Node) hck.Builder {
+	return element(atom.Dialog, children)
+}
+
+// Div creates a <div> element with children.
+func Div(children ...*hck.Node) hck.Builder {
+	return element(atom.Div, children)
+}
+
+// Dl creates a <dl> element with children.
+func Dl(children ...*hck.Node) hck.Builder {
+	return element(atom.Dl, children)
+}
+
+// Dt creates a <dt> element with children.
+func Dt(children ...*hck.Node) hck.Builder {
+	return element(atom.Dt, children)
+}
+
+// Em creates an <em> element with children.
+func Em(children ...*hck.Node) hck.Builder {
+	return element(atom.Em, children)
+}
+
+// Embed creates an <embed> element, it can not have children.
+func Embed() hck.Builder {
+	return element(atom.Embed, nil)
+}
+
+// Fieldset creates a <fieldset> element with children.
+func Fieldset(children ...*hck.Node) hck.Builder {
+	return element(atom.Fieldset, children)
+}
+
+// Figcaption creates a <figcaption> element with children.
+func Figcaption(children ...*hck.Node) hck.Builder {
+	return element(atom.Figcaption, children)
+}
+
+// Figure creates a <figure> element with children.
+func Figure(children ...*hck.Node) hck.Builder {
+	return element(atom.Figure, children)
+}
+
+// Footer creates a <footer> element with children.
+func Footer(children ...*hck.Node) hck.Builder {
+	return element(atom.Footer, children)
+}
+
+// Form creates a <form> element with children.
+func Form(children ...*hck.Node) hck.Builder {
+	return element(atom.Form, children)
+}
+
+// H1 creates a <h1> element with children.
+func H1(children ...*hck.Node) hck.Builder {
+	return element(atom.H1, children)
+}
+
+// H2 creates a <h2> element with children.
+func H2(children ...*hck.Node) hck.Builder {
+	return element(atom.H2, children)
+}
+
+// H3 creates a <h3> element with children.
+func H3(children ...*hck.Node) hck.Builder {
+	return element(atom.H3, children)
+}
+
+// H4 creates a <h4> element with children.
+func H4(children ...*hck.Node) hck.Builder {
+	return element(atom.H4, children)
+}
+
+// H5 creates a <h5> element with children.
+func H5(children ...*hck.Node) hck.Builder {
+	return element(atom.H5, children)
+}
+
+// H6 creates a <h6> element with children.
+func H6(children ...*hck.Node) hck.Builder {
+	return element(atom.H6, children)
+}
+
+// Head creates a <head> element with children.
+func Head(children ...*hck.Node) hck.Builder {
+	return element(atom.Head, children)
+}
+
+// Header creates a <header> element with children.
+func Header(children ...*hck.Node) hck.Builder {
+	return element(atom.Header, children)
+}
+
+// Hgroup creates a <hgroup> element with children.
+func Hgroup(children ...*hck.Node) hck.Builder {
+	return element(atom.Hgroup, children)
+}
+
+// Hr creates a <hr> element, it can not have children.
+func Hr() hck.Builder {
+	return element(atom.Hr, nil)
+}
+
+// Html creates a <html> element with children.
+func Html(children ...*hck.Node) hck.Builder {
+	return element(atom.Html, children)
+}
+
+// I creates an <i> element with children.
+func I(children ...*hck.Node) hck.Builder {
+	return element(atom.I, children)
+}
+
+// Iframe creates an <iframe> element with children.
+func Iframe(children ...*hck.Node) hck.Builder {
+	return element(atom.Iframe, children)
+}
+
+// Img creates an <img> element, it can not have children.
+func Img(src, alt string) hck.Builder {
+	return element(atom.Img, nil).
+		Attr(atom.Src.String(), src).
+		Attr(atom.Alt.String(), alt)
+}
+
+// Input creates an <input> element, it can not have children.
+func Input() hck.Builder {
+	return element(atom.Input, nil)
+}
+
+// Ins creates an <ins> element with children.
+func Ins(children ...*hck.Node) hck.Builder {
+	return element(atom.Ins, children)
+}
+
+// Kbd creates a <kbd> element with children.
+func Kbd(children ...*hck.Node) hck.Builder {
+	return element(atom.Kbd, children)
+}
+
+// Label creates a <label> element with children.
+func Label(children ...*hck.Node) hck.Builder {
+	return element(atom.Label, children)
+}
+
+// Legend creates a <legend> element with children.
+func Legend(children ...*hck.Node) hck.Builder {
+	return element(atom.Legend, children)
+}
+
+// Li creates a <li> element with children.
+func Li(children ...*hck.Node) hck.Builder {
+	return element(atom.Li, children)
+}
+
+// Link creates a <link> element, it can not have children.
+func Link(rel, href string) hck.Builder {
+	return element(atom.Link, nil).
+		Attr(atom.Rel.String(), rel).
+		Attr(atom.Href.String(), href)
+}
+
+// Main creates a <main> element with children.
+func Main(children ...*hck.Node) hck.Builder {
+	return element(atom.Main, children)
+}
+
+// Map creates a <map> element with children.
+func Map(children ...*hck.Node) hck.Builder {
+	return element(atom.Map, children)
+}
+
+// Mark creates a <mark> element with children.
+func Mark(children ...*hck.Node) hck.Builder {
+	return element(atom.Mark, children)
+}
+
+// Menu creates a <menu> element with children.
+func Menu(children ...*hck.Node) hck.Builder {
+	return element(atom.Menu, children)
+}
+
+// Meta creates a <meta> element, it can not have children.
+func Meta() hck.Builder {
+	return element(atom.Meta, nil)
+}
+
+// Meter creates a <meter> element with children.
+func Meter(children ...*hck.Node) hck.Builder {
+	return element(atom.Meter, children)
+}
+
+// Nav creates a <nav> element with children.
+func Nav(children ...*hck.Node) hck.Builder {
+	return element(atom.Nav, children)
+}
+
+// Noscript creates a <noscript> element with children.
+func Noscript(children ...*hck.Node) hck.Builder {
+	return element(atom.Noscript, children)
+}
+
+// Object creates an <object> element with children.
+func Object(children ...*hck.Node) hck.Builder {
+	return element(atom.Object, children)
+}
+
+// Ol creates an <ol> element with children.
+func Ol(children ...*hck.Node) hck.Builder {
+	return element(atom.Ol, children)
+}
+
+// Optgroup creates an <optgroup> element with children.
+func Optgroup(children ...*hck.Node) hck.Builder {
+	return element(atom.Optgroup, children)
+}
+
+// Option creates an <option> element with children.
+func Option(children ...*hck.Node) hck.Builder {
+	return element(atom.Option, children)
+}
+
+// Output creates an <output> element with children.
+func Output(children ...*hck.Node) hck.Builder {
+	return element(atom.Output, children)
+}
+
+// P creates a <p> element with children.
+func P(children ...*hck.Node) hck.Builder {
+	return element(atom.P, children)
+}
+
+// Picture creates a <picture> element with children.
+func Picture(children ...*hck.Node) hck.Builder {
+	return element(atom.Picture, children)
+}
+
+// Pre creates a <pre> element with children.
+func Pre(children ...*hck.Node) hck.Builder {
+	return element(atom.Pre, children)
+}
+
+// Progress creates a <progress> element with children.
+func Progress(children ...*hck.Node) hck.Builder {
+	return element(atom.Progress, children)
+}
+
+// Q creates a <q> element with children.
+func Q(children ...*hck.Node) hck.Builder {
+	return element(atom.Q, children)
+}
+
+// Rp creates a <rp> element with children.
+func Rp(children ...*hck.Node) hck.Builder {
+	return element(atom.Rp, children)
+}
+
+// Rt creates a <rt> element with children.
+func Rt(children ...*hck.Node) hck.Builder {
+	return element(atom.Rt, children)
+}
+
+// Ruby creates a <ruby> element with children.
+func Ruby(children ...*hck.Node) hck.Builder {
+	return element(atom.Ruby, children)
+}
+
+// S creates a <s> element with children.
+func S(children ...*hck.Node) hck.Builder {
+	return element(atom.S, children)
+}
+
+// Samp creates a <samp> element with children.
+func Samp(children ...*hck.Node) hck.Builder {
+	return element(atom.Samp, children)
+}
+
+// Script creates a <script> element with children.
+func Script(children ...*hck.Node) hck.Builder {
+	return element(atom.Script, children)
+}
+
+// Search creates a <search> element with children.
+func Search(children ...*hck.Node) hck.Builder {
+	return element(atom.Search, children)
+}
+
+// Section creates a <section> element with children.
+func Section(children ...*hck.Node) hck.Builder {
+	return element(atom.Section, children)
+}
+
+// Select creates a <select> element with children.
+func Select(children ...*hck.Node) hck.Builder {
+	return element(atom.Select, children)
+}
+
+// Slot creates a <slot> element with children.
+func Slot(children ...*hck.Node) hck.Builder {
+	return element(atom.Slot, children)
+}
+
+// Small creates a <small> element with children.
+func Small(children ...*hck.Node) hck.Builder {
+	return element(atom.Small, children)
+}
+
+// Source creates a <source> element, it can not have children.
+func Source() hck.Builder {
+	return element(atom.Source, nil)
+}
+
+// Span creates a <span> element with children.
+func Span(children ...*hck.Node) hck.Builder {
+	return element(atom.Span, children)
+}
+
+// Strong creates a <strong> element with children.
+func Strong(children ...*hck.Node) hck.Builder {
+	return element(atom.Strong, children)
+}
+
+// Style creates a <style> element with children.
+func Style(children ...*hck.Node) hck.Builder {
+	return element(atom.Style, children)
+}
+
+// Sub creates a <sub> element with children.
+func Sub(children ...*hck.Node) hck.Builder {
+	return element(atom.Sub, children)
+}
+
+// Summary creates a <summary> element with children.
+func Summary(children ...*hck.Node) hck.Builder {
+	return element(atom.Summary, children)
+}
+
+// Sup creates a <sup> element with children.
+func Sup(children ...*hck.Node) hck.Builder {
+	return element(atom.Sup, children)
+}
+
+// Table creates a <table> element with children.
+func Table(children ...*hck.Node) hck.Builder {
+	return element(atom.Table, children)
+}
+
+// Tbody creates a <tbody> element with children.
+func Tbody(children ...*hck.Node) hck.Builder {
+	return element(atom.Tbody, children)
+}
+
+// Td creates a <td> element with children.
+func Td(children ...*hck.Node) hck.Builder {
+	return element(atom.Td, children)
+}
+
+// Template creates a <template> element with children.
+func Template(children ...*hck.Node) hck.Builder {
+	return element(atom.Template, children)
+}
+
+// Textarea creates a <textarea> element with children.
+func Textarea(children ...*hck.Node) hck.Builder {
+	return element(atom.Textarea, children)
+}
+
+// Tfoot creates a <tfoot> element with children.
+func Tfoot(children ...*hck.Node) hck.Builder {
+	return element(atom.Tfoot, children)
+}
+
+// Th creates a <th> element with children.
+func Th(children ...*hck.Node) hck.Builder {
+	return element(atom.Th, children)
+}
+
+// Thead creates a <thead> element with children.
+func Thead(children ...*hck.Node) hck.Builder {
+	return element(atom.Thead, children)
+}
+
+// Time creates a <time> element with children.
+func Time(children ...*hck.Node) hck.Builder {
+	return element(atom.Time, children)
+}
+
+// Title creates a <title> element with children.
+func Title(children ...*hck.Node) hck.Builder {
+	return element(atom.Title, children)
+}
+
+// Tr creates a <tr> element with children.
+func Tr(children ...*hck.Node) hck.Builder {
+	return element(atom.Tr, children)
+}
+
+// Track creates a <track> element, it can not have children.
+func Track() hck.Builder {
+	return element(atom.Track, nil)
+}
+
+// U creates an <u> element with children.
+func U(children ...*hck.Node) hck.Builder {
+	return element(atom.U, children)
+}
+
+// Ul creates an <ul> element with children.
+func Ul(children ...*hck.Node) hck.Builder {
+	return element(atom.Ul, children)
+}
+
+// Var creates a <var> element with children.
+func Var(children ...*hck.Node) hck.Builder {
+	return element(atom.Var, children)
+}
+
+// Video creates a <video> element with children.
+func Video(children ...*hck.Node) hck.Builder {
+	return element(atom.Video, children)
+}
+
+// Wbr creates a <wbr> element, it can not have children.
+func Wbr() hck.Builder {
+	return element(atom.Wbr, nil)
+}
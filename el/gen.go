@@ -0,0 +1,102 @@
+//go:build ignore
+
+// gen generates the element constructors in elements.go.
+// The constructors reference the atoms of golang.org/x/net/html/atom,
+// void elements are determined by hck.IsVoidElement.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/arnehormann/hck"
+	"golang.org/x/net/html/atom"
+)
+
+// elements of the HTML living standard,
+// see html.spec.whatwg.org/multipage/indices.html#elements-3
+var elements = []string{
+	"a", "abbr", "address", "area", "article", "aside", "audio",
+	"b", "base", "bdi", "bdo", "blockquote", "body", "br", "button",
+	"canvas", "caption", "cite", "code", "col", "colgroup",
+	"data", "datalist", "dd", "del", "details", "dfn", "dialog", "div", "dl", "dt",
+	"em", "embed",
+	"fieldset", "figcaption", "figure", "footer", "form",
+	"h1", "h2", "h3", "h4", "h5", "h6", "head", "header", "hgroup", "hr", "html",
+	"i", "iframe", "img", "input", "ins",
+	"kbd",
+	"label", "legend", "li", "link",
+	"main", "map", "mark", "menu", "meta", "meter",
+	"nav", "noscript",
+	"object", "ol", "optgroup", "option", "output",
+	"p", "picture", "pre", "progress",
+	"q",
+	"rp", "rt", "ruby",
+	"s", "samp", "script", "search", "section", "select", "slot", "small", "source", "span", "strong", "style", "sub", "summary", "sup",
+	"table", "tbody", "td", "template", "textarea", "tfoot", "th", "thead", "time", "title", "tr", "track",
+	"u", "ul",
+	"var", "video",
+	"wbr",
+}
+
+// required attributes become parameters of the constructor
+var required = map[string][]string{
+	"a":    {"href"},
+	"img":  {"src", "alt"},
+	"link": {"rel", "href"},
+}
+
+func main() {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen.go; DO NOT EDIT.\n\n")
+	b.WriteString("package el\n\n")
+	b.WriteString("import (\n\t\"github.com/arnehormann/hck\"\n\t\"golang.org/x/net/html/atom\"\n)\n")
+	for _, tag := range elements {
+		if atom.Lookup([]byte(tag)).String() != tag {
+			log.Fatalf("%s is not in the atom table", tag)
+		}
+		name := strings.ToUpper(tag[:1]) + tag[1:]
+		attrs := required[tag]
+		void := hck.IsVoidElement(tag)
+		sig := strings.Join(attrs, ", ")
+		if len(attrs) > 0 {
+			sig += " string"
+		}
+		article := "a"
+		if strings.ContainsRune("aeiou", rune(tag[0])) {
+			article = "an"
+		}
+		fmt.Fprintf(&b, "\n// %s creates %s <%s> element", name, article, tag)
+		switch {
+		case void:
+			b.WriteString(", it can not have children.\n")
+		default:
+			b.WriteString(" with children.\n")
+			if sig != "" {
+				sig += ", "
+			}
+			sig += "children ...*hck.Node"
+		}
+		children := "children"
+		if void {
+			children = "nil"
+		}
+		fmt.Fprintf(&b, "func %s(%s) hck.Builder {\n", name, sig)
+		fmt.Fprintf(&b, "\treturn element(atom.%s, %s)", name, children)
+		for _, a := range attrs {
+			fmt.Fprintf(&b, ".\n\t\tAttr(atom.%s.String(), %s)", strings.ToUpper(a[:1])+a[1:], a)
+		}
+		b.WriteString("\n}\n")
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("elements.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
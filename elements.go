@@ -0,0 +1,36 @@
+package hck
+
+import "golang.org/x/net/html"
+
+// voidElements can not have children, see
+// html.spec.whatwg.org/multipage/syntax.html#void-elements
+var voidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"keygen": true,
+	"link":   true,
+	"meta":   true,
+	"param":  true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+// IsVoidElement reports whether tag is the name of an HTML element which can not have children.
+func IsVoidElement(tag string) bool {
+	return voidElements[tag]
+}
+
+// IsVoid reports whether n is an HTML element which can not have children.
+func (n *Node) IsVoid() bool {
+	return n != nil &&
+		n.Type == html.ElementNode &&
+		n.Namespace == "" &&
+		voidElements[n.Data]
+}
@@ -1,6 +1,17 @@
 package hck
 
 func (n *Node) Find(ms ...Matcher) *Finder {
+	return newFinder(n.Cursor(), ms)
+}
+
+// Find creates a finder starting at the current node.
+// It can search forward with Next and backward with Prev and is not
+// restricted to the subtree of the current node.
+func (c *Cursor) Find(ms ...Matcher) *Finder {
+	return newFinder(c.Cursor(), ms)
+}
+
+func newFinder(c *Cursor, ms []Matcher) *Finder {
 	if len(ms) == 0 {
 		return &Finder{}
 	}
@@ -8,13 +19,23 @@ func (n *Node) Find(ms ...Matcher) *Finder {
 	for i := range ms {
 		fs[i].Matcher = ms[i]
 	}
-	fs[0].Cursor = n.Cursor()
+	fs[0].Cursor = c
 	return &Finder{fs}
 }
 
+// FindLast is like Find but the finder starts behind the last node,
+// use Prev to search in reverse document order.
+func (n *Node) FindLast(ms ...Matcher) *Finder {
+	f := n.Find(ms...)
+	if len(f.finders) > 0 {
+		f.finders[0].end = true
+	}
+	return f
+}
+
 func (r *Node) PathTo(n *Node) Path {
 	f := Document(r).Find(n)
-	f.next()
+	f.Next()
 	p := f.Path()
 	if len(p) == 0 {
 		return p
@@ -35,56 +56,129 @@ func (n *Node) Each(m Matcher, f func(n *Node)) {
 type finder struct {
 	*Cursor
 	Matcher
+
+	// the cursor is on a match
+	found bool
+
+	// the cursor is behind the last node it can reach
+	end bool
+}
+
+// seekNext moves to the next match.
+func (f *finder) seekNext() bool {
+	f.found = !f.end && f.Seek(f.Matcher)
+	// Cursor.Next returns to the top node after the last one
+	f.end = !f.found
+	return f.found
+}
+
+// seekPrev moves to the previous match.
+// The node the cursor is restricted to is never matched.
+func (f *finder) seekPrev() bool {
+	f.found = false
+	if f.end {
+		f.end = false
+		if f.LastChild() == nil {
+			return false
+		}
+		for f.LastChild() != nil {
+		}
+		if Matches(f.Matcher, f.Cursor) {
+			f.found = true
+			return true
+		}
+	}
+	for f.Depth() > f.top && f.Prev() != nil && f.Depth() > f.top {
+		if Matches(f.Matcher, f.Cursor) {
+			f.found = true
+			return true
+		}
+	}
+	return false
 }
 
 type finders []finder
 
-func (fs finders) next() finders {
+// next moves to the next node matched by all finders.
+// Each finder searches the subtree of the node found by its predecessor.
+// If no further match exists, it returns false.
+func (fs finders) next() bool {
 	if len(fs) == 0 || fs[0].Cursor == nil {
-		return fs[:0]
+		return false
 	}
-nextInner:
-	if fn := fs[1:]; len(fn) > 0 {
-		if inner := fn.next(); len(inner) > 0 {
-			return fs
+	for {
+		if len(fs) > 1 && fs[1].Cursor != nil && fs[1:].next() {
+			return true
+		}
+		if !fs[0].seekNext() {
+			fs.reset()
+			return false
+		}
+		if len(fs) == 1 {
+			return true
+		}
+		fs[1] = finder{
+			Cursor:  fs[0].sub(),
+			Matcher: fs[1].Matcher,
 		}
 	}
-	// search match
-	f0 := fs[0]
+}
+
+// prev moves to the previous node matched by all finders.
+// If no previous match exists, it returns false.
+func (fs finders) prev() bool {
+	if len(fs) == 0 || fs[0].Cursor == nil {
+		return false
+	}
 	for {
-		n := f0.Next()
-		if n == nil {
-			f0.Cursor = nil
-			return nil
+		if len(fs) > 1 && fs[1].Cursor != nil && fs[1:].prev() {
+			return true
 		}
-		if !f0.Match(n) {
-			continue
+		if !fs[0].seekPrev() {
+			fs.reset()
+			return false
 		}
 		if len(fs) == 1 {
-			return fs
+			return true
 		}
-		if len(n.Children) > 0 {
-			fs[1].Cursor = n.Children[0].Cursor()
-			goto nextInner
+		fs[1] = finder{
+			Cursor:  fs[0].sub(),
+			Matcher: fs[1].Matcher,
+			end:     true,
 		}
 	}
 }
 
-func (fs finders) appendPath(dest Path) Path {
-	for _, f := range fs {
-		if f.Cursor == nil {
-			return dest
+// reset deactivates all inner finders.
+func (fs finders) reset() {
+	for i := 1; i < len(fs); i++ {
+		fs[i].Cursor = nil
+	}
+}
+
+// cursor retrieves the innermost active cursor if it is on a match.
+func (fs finders) cursor() *Cursor {
+	for i := len(fs) - 1; i >= 0; i-- {
+		if f := fs[i]; f.Cursor != nil {
+			if f.found {
+				return f.Cursor
+			}
+			return nil
 		}
-		dest = f.appendPath(dest)
+	}
+	return nil
+}
+
+func (fs finders) appendPath(dest Path) Path {
+	if c := fs.cursor(); c != nil {
+		return c.appendPath(dest)
 	}
 	return dest
 }
 
 func (fs finders) Node() *Node {
-	for i := len(fs) - 1; i >= 0; i-- {
-		if c := fs[i].Cursor; c != nil {
-			return c.Node()
-		}
+	if c := fs.cursor(); c != nil {
+		return c.Node()
 	}
 	return nil
 }
@@ -108,16 +202,28 @@ func (f Finder) Find(m Matcher) *Finder {
 	}
 }
 
+// Next moves to and retrieves the next found node in document order.
+// If no further node is found, it returns nil.
 func (f Finder) Next() *Node {
-	fs := f.next()
-	if fs == nil {
+	if !f.next() {
+		return nil
+	}
+	return f.Node()
+}
+
+// Prev moves to and retrieves the previous found node in document order.
+// After Next returned nil, Prev retrieves the last found node.
+// If no previous node is found, it returns nil.
+func (f Finder) Prev() *Node {
+	if !f.prev() {
 		return nil
 	}
-	f.finders = fs
 	return f.Node()
 }
 
 // Each iterates over all found nodes and calls do.
+// The path is only valid during the call, its backing array is reused.
+// Use Path or Paths to retrieve paths which can be retained.
 func (f *Finder) Each(do func(Path) (stop bool)) {
 	var path Path
 	var stop bool
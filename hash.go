@@ -0,0 +1,37 @@
+package hck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Digest is the SHA-256 hash of a canonically rendered tree.
+type Digest [sha256.Size]byte
+
+// String retrieves the digest in hexadecimal.
+func (d Digest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+// ETag retrieves the digest as a strong HTTP entity tag.
+func (d Digest) ETag() string {
+	return `"` + d.String() + `"`
+}
+
+// Hash retrieves the digest of the canonical rendering of the node.
+// Trees only differing in attribute order, class separators
+// or the escaping of the parsed input have the same digest.
+func (n *Node) Hash() (Digest, error) {
+	return Siblings{n}.Hash()
+}
+
+// Hash retrieves the digest of the canonical rendering of the nodes.
+func (s Siblings) Hash() (Digest, error) {
+	var d Digest
+	h := sha256.New()
+	if err := s.RenderWithOptions(h, RenderOptions{Canonical: true}); err != nil {
+		return d, err
+	}
+	h.Sum(d[:0])
+	return d, nil
+}
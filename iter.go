@@ -0,0 +1,103 @@
+//go:build go1.23
+
+package hck
+
+import "iter"
+
+// All retrieves an iterator over n and all its descendants in document order.
+func (n *Node) All() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		if n == nil || !yield(n) {
+			return
+		}
+		c := n.Cursor()
+		for d := c.Next(); d != nil; d = c.Next() {
+			if !yield(d) {
+				return
+			}
+		}
+	}
+}
+
+// Descendants retrieves an iterator over all descendants of n matching m in document order.
+// If m is nil, all descendants are matched.
+func (n *Node) Descendants(m Matcher) iter.Seq[*Node] {
+	if m == nil {
+		m = Match(func(*Node) bool { return true })
+	}
+	return n.Find(m).Nodes()
+}
+
+// Nodes retrieves an iterator over the remaining found nodes.
+// Each iteration advances the finder, after breaking out of the loop
+// Next continues after the last retrieved node.
+func (f *Finder) Nodes() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		for n := f.Next(); n != nil; n = f.Next() {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// Paths retrieves an iterator over the paths to the remaining found nodes.
+// Each path is newly allocated and owned by the caller, it may be retained and modified.
+// Each iteration advances the finder, after breaking out of the loop
+// Next continues after the last retrieved node.
+func (f *Finder) Paths() iter.Seq[Path] {
+	return func(yield func(Path) bool) {
+		for n := f.Next(); n != nil; n = f.Next() {
+			if !yield(f.Path()) {
+				return
+			}
+		}
+	}
+}
+
+// Elements retrieves an iterator over the element nodes and their indices.
+func (s Siblings) Elements() iter.Seq2[int, *Node] {
+	return func(yield func(int, *Node) bool) {
+		for i, n := range s {
+			if isElement(n) && !yield(i, n) {
+				return
+			}
+		}
+	}
+}
+
+// Following retrieves an iterator moving the cursor to the depth-first next nodes.
+// After breaking out of the loop, the cursor stays on the last retrieved node.
+func (c *Cursor) Following() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		for n := c.Next(); n != nil; n = c.Next() {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// Preceding retrieves an iterator moving the cursor to the depth-first previous nodes.
+// After breaking out of the loop, the cursor stays on the last retrieved node.
+func (c *Cursor) Preceding() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		for n := c.Prev(); n != nil; n = c.Prev() {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// Ancestors retrieves an iterator over the ancestors of the current node, starting with its parent.
+// The cursor does not move.
+func (c *Cursor) Ancestors() iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		for i := len(c.path) - 2; i >= 0; i-- {
+			if !yield(c.path[i]) {
+				return
+			}
+		}
+	}
+}
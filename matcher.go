@@ -1,11 +1,33 @@
 package hck
 
-import "golang.org/x/net/html"
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
 
 type Matcher interface {
 	Match(*Node) bool
 }
 
+// CursorMatcher is a Matcher which takes the position of a node in its tree into account.
+// MatchCursor must neither move nor retain the cursor.
+//
+// Match is used when no context is available, the node is then treated as a root.
+type CursorMatcher interface {
+	Matcher
+	MatchCursor(c *Cursor) bool
+}
+
+// Matches reports whether m matches the current node of c.
+// CursorMatchers are provided with the cursor.
+func Matches(m Matcher, c *Cursor) bool {
+	if cm, ok := m.(CursorMatcher); ok {
+		return cm.MatchCursor(c)
+	}
+	return m.Match(c.Node())
+}
+
 type Match func(*Node) bool
 
 func (m Match) Match(n *Node) bool {
@@ -35,6 +57,16 @@ func (ms matchAll) Match(n *Node) bool {
 	return true
 }
 
+func (ms matchAll) MatchCursor(c *Cursor) bool {
+	for _, m := range ms {
+		if !Matches(m, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchAny matches nodes matched by any of ms (CSS ":is()" and ":where()").
 func MatchAny(ms ...Matcher) Matcher {
 	return matchAny(ms)
 }
@@ -50,6 +82,32 @@ func (ms matchAny) Match(n *Node) bool {
 	return false
 }
 
+func (ms matchAny) MatchCursor(c *Cursor) bool {
+	for _, m := range ms {
+		if Matches(m, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchNot matches nodes not matched by m (CSS ":not()").
+func MatchNot(m Matcher) Matcher {
+	return matchNot{m}
+}
+
+type matchNot struct {
+	m Matcher
+}
+
+func (m matchNot) Match(n *Node) bool {
+	return !m.m.Match(n)
+}
+
+func (m matchNot) MatchCursor(c *Cursor) bool {
+	return !Matches(m.m, c)
+}
+
 func MatchTag(tag string) Matcher {
 	_, tag = atomize(tag)
 	return matchTag(tag)
@@ -104,13 +162,23 @@ type matchChild struct {
 	m Matcher
 }
 
+// MatchChild matches nodes with a child matching m (CSS ":has(> child)").
 func MatchChild(m Matcher) Matcher {
 	return matchChild{m: m}
 }
 
 func (m matchChild) Match(n *Node) bool {
-	return n != nil &&
-		n.Children.Index(m.m) >= 0
+	return matchRoot(m, n)
+}
+
+func (m matchChild) MatchCursor(c *Cursor) bool {
+	s := c.sub()
+	for n := s.FirstChild(); n != nil; n = s.NextSibling() {
+		if Matches(m.m, s) {
+			return true
+		}
+	}
+	return false
 }
 
 func MatchType(t html.NodeType) Matcher {
@@ -136,3 +204,99 @@ func (m matchNamespace) Match(n *Node) bool {
 	return n != nil &&
 		n.Namespace == m.namespace
 }
+
+func MatchID(id string) Matcher {
+	return MatchAttribute(attrID, "", id)
+}
+
+func MatchClass(class string) Matcher {
+	return &matchAttributeOp{
+		Key: attrClass,
+		Val: class,
+		op:  '~',
+	}
+}
+
+// MatchHasAttribute matches nodes with the attribute, regardless of its value.
+func MatchHasAttribute(key, namespace string) Matcher {
+	return matchAttributeOperator(key, namespace, "", 0)
+}
+
+// MatchAttributeWord matches nodes where the attribute is a whitespace separated
+// list containing value (CSS [key~=value]).
+func MatchAttributeWord(key, namespace, value string) Matcher {
+	return matchAttributeOperator(key, namespace, value, '~')
+}
+
+// MatchAttributeLang matches nodes where the attribute is value
+// or starts with value followed by "-" (CSS [key|=value]).
+func MatchAttributeLang(key, namespace, value string) Matcher {
+	return matchAttributeOperator(key, namespace, value, '|')
+}
+
+// MatchAttributePrefix matches nodes where the attribute starts with value (CSS [key^=value]).
+func MatchAttributePrefix(key, namespace, value string) Matcher {
+	return matchAttributeOperator(key, namespace, value, '^')
+}
+
+// MatchAttributeSuffix matches nodes where the attribute ends with value (CSS [key$=value]).
+func MatchAttributeSuffix(key, namespace, value string) Matcher {
+	return matchAttributeOperator(key, namespace, value, '$')
+}
+
+// MatchAttributeContains matches nodes where the attribute contains value (CSS [key*=value]).
+func MatchAttributeContains(key, namespace, value string) Matcher {
+	return matchAttributeOperator(key, namespace, value, '*')
+}
+
+func matchAttributeOperator(key, namespace, value string, op byte) Matcher {
+	_, key = atomize(key)
+	return &matchAttributeOp{
+		Namespace: namespace,
+		Key:       key,
+		Val:       value,
+		op:        op,
+	}
+}
+
+type matchAttributeOp struct {
+	Namespace string
+	Key       string
+	Val       string
+	op        byte
+}
+
+func (m *matchAttributeOp) Match(n *Node) bool {
+	attr := n.Attribute(m.Key, m.Namespace)
+	if attr == nil {
+		return false
+	}
+	val := attr.Val
+	switch m.op {
+	case 0:
+		return true
+	case '~':
+		for _, w := range Classes(val) {
+			if w == m.Val {
+				return true
+			}
+		}
+		return false
+	case '|':
+		return val == m.Val ||
+			strings.HasPrefix(val, m.Val) && len(val) > len(m.Val) && val[len(m.Val)] == '-'
+	}
+	// an empty value never matches for substring operators
+	if m.Val == "" {
+		return false
+	}
+	switch m.op {
+	case '^':
+		return strings.HasPrefix(val, m.Val)
+	case '$':
+		return strings.HasSuffix(val, m.Val)
+	case '*':
+		return strings.Contains(val, m.Val)
+	}
+	return false
+}
@@ -0,0 +1,359 @@
+package hck
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ConflictKind is the kind of a Conflict.
+type ConflictKind int
+
+const (
+	// ConflictReplace is reported when both sides replaced a node differently.
+	ConflictReplace ConflictKind = iota
+	// ConflictData is reported when both sides changed the data of a text or comment node differently.
+	ConflictData
+	// ConflictAttr is reported when both sides changed an attribute differently.
+	ConflictAttr
+	// ConflictInsert is reported when both sides inserted different nodes at the same position.
+	ConflictInsert
+	// ConflictDelete is reported when one side deleted a node the other one changed.
+	ConflictDelete
+	// ConflictMove is reported when both sides reordered the children of a node differently.
+	ConflictMove
+)
+
+var conflictKindNames = [...]string{
+	ConflictReplace: "replace",
+	ConflictData:    "data",
+	ConflictAttr:    "attribute",
+	ConflictInsert:  "insert",
+	ConflictDelete:  "delete",
+	ConflictMove:    "move",
+}
+
+func (k ConflictKind) String() string {
+	if k < 0 || int(k) >= len(conflictKindNames) {
+		return "ConflictKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return conflictKindNames[k]
+}
+
+// Conflict is a change both sides of a merge made differently.
+type Conflict struct {
+	Kind ConflictKind
+
+	// Path of the conflicting node in the merged tree.
+	// For deleted nodes, it is the position the node would have.
+	// For attribute and move conflicts, it is the path of the changed node.
+	Path IndexPath
+
+	// Namespace and Key of a conflicting attribute
+	Namespace string
+	Key       string
+
+	// Base, Ours and Theirs are the conflicting nodes in the input trees,
+	// they are empty if a side has none.
+	Base   Siblings
+	Ours   Siblings
+	Theirs Siblings
+}
+
+func (c Conflict) String() string {
+	s := c.Kind.String() + " conflict " + c.Path.String()
+	if c.Kind == ConflictAttr {
+		s += " " + attrName(c.Namespace, c.Key)
+	}
+	return s
+}
+
+// Conflicts are the conflicts of a merge.
+type Conflicts []Conflict
+
+// String retrieves a report with one conflict per line.
+func (cs Conflicts) String() string {
+	var b strings.Builder
+	for _, c := range cs {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// MergeOptions configure MergeWithOptions.
+type MergeOptions struct {
+	// Markers embeds node conflicts in the merged tree as
+	// <merge-conflict><merge-ours>...</merge-ours><merge-theirs>...</merge-theirs></merge-conflict>.
+	// Attribute and move conflicts have no markers.
+	Markers bool
+}
+
+// Merge retrieves a new tree containing the changes from base to ours
+// and from base to theirs.
+// It is MergeWithOptions without markers.
+func Merge(base, ours, theirs *Node) (*Node, Conflicts) {
+	return MergeWithOptions(base, ours, theirs, MergeOptions{})
+}
+
+// MergeWithOptions retrieves a new tree containing the changes from base to ours
+// and from base to theirs.
+//
+// Nodes are matched like in Diff. Changes of only one side are applied,
+// changes made by both sides are conflicts unless they are equal.
+// Without markers, ours is used for conflicts.
+// The nodes of base, ours and theirs are not modified or shared with the result.
+func MergeWithOptions(base, ours, theirs *Node, opts MergeOptions) (*Node, Conflicts) {
+	m := merger{opts: opts, subtrees: subtrees{}}
+	ns := m.node(IndexPath{}, base, ours, theirs)
+	if len(ns) == 0 {
+		return nil, m.conflicts
+	}
+	return ns[0], m.conflicts
+}
+
+type merger struct {
+	opts      MergeOptions
+	conflicts Conflicts
+	subtrees  subtrees
+}
+
+// conflict records c and retrieves the nodes for the merged tree.
+func (m *merger) conflict(c Conflict) Siblings {
+	m.conflicts = append(m.conflicts, c)
+	if !m.opts.Markers {
+		return deepClones(c.Ours)
+	}
+	return Siblings{Tag("merge-conflict").Children(
+		Tag("merge-ours").Children(deepClones(c.Ours)...).Node(),
+		Tag("merge-theirs").Children(deepClones(c.Theirs)...).Node(),
+	).Node()}
+}
+
+// node merges the matching nodes b, o and t at p.
+func (m *merger) node(p IndexPath, b, o, t *Node) Siblings {
+	switch {
+	case equal(b, o):
+		return deepClones(siblingsOf(t))
+	case equal(b, t) || equal(o, t):
+		return deepClones(siblingsOf(o))
+	case !similar(b, o) || !similar(b, t):
+		return m.conflict(Conflict{Kind: ConflictReplace, Path: p,
+			Base: siblingsOf(b), Ours: siblingsOf(o), Theirs: siblingsOf(t)})
+	}
+	n := &Node{
+		Namespace: o.Namespace,
+		Data:      o.Data,
+		Type:      o.Type,
+	}
+	switch {
+	case o.Data == t.Data || t.Data == b.Data:
+	case o.Data == b.Data:
+		n.Data = t.Data
+	default:
+		return m.conflict(Conflict{Kind: ConflictData, Path: p,
+			Base: Siblings{b}, Ours: Siblings{o}, Theirs: Siblings{t}})
+	}
+	m.attributes(p, n, b, o, t)
+	n.Children = m.children(p, b, o, t)
+	return Siblings{n}
+}
+
+// attributes sets the merged attributes of b, o and t in n.
+func (m *merger) attributes(p IndexPath, n, b, o, t *Node) {
+	same := func(x, y *html.Attribute) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return x.Val == y.Val
+	}
+	merge := func(a html.Attribute) {
+		ba := b.Attributes.find(a.Key, a.Namespace)
+		oa := o.Attributes.find(a.Key, a.Namespace)
+		ta := t.Attributes.find(a.Key, a.Namespace)
+		v := oa
+		switch {
+		case same(oa, ta) || same(ba, ta):
+		case same(ba, oa):
+			v = ta
+		default:
+			m.conflicts = append(m.conflicts, Conflict{Kind: ConflictAttr, Path: p,
+				Namespace: a.Namespace, Key: a.Key,
+				Base: Siblings{b}, Ours: Siblings{o}, Theirs: Siblings{t}})
+		}
+		if v != nil {
+			n.Attributes = append(n.Attributes, *v)
+		}
+	}
+	for _, a := range o.Attributes {
+		merge(a)
+	}
+	for _, a := range t.Attributes {
+		if o.Attributes.find(a.Key, a.Namespace) == nil {
+			merge(a)
+		}
+	}
+}
+
+// children merges the children of the matching nodes b, o and t at p.
+func (m *merger) children(p IndexPath, b, o, t *Node) Siblings {
+	bs, os, ts := b.Children, o.Children, t.Children
+	po, pt := matchSiblings(m.subtrees, bs, os), matchSiblings(m.subtrees, bs, ts)
+	seqO, insO := sequence(po, os)
+	seqT, insT := sequence(pt, ts)
+	seq := seqT
+	if !sort.IntsAreSorted(seqO) {
+		seq = seqO
+		if !sort.IntsAreSorted(seqT) && !sameOrder(seqO, seqT, po, pt) {
+			m.conflicts = append(m.conflicts, Conflict{Kind: ConflictMove, Path: p,
+				Base: Siblings{b}, Ours: Siblings{o}, Theirs: Siblings{t}})
+		}
+	}
+	// order contains all indexes in bs, deleted nodes follow their previous sibling in bs
+	order := make([]int, 0, len(bs))
+	if sort.IntsAreSorted(seq) {
+		for i := range bs {
+			order = append(order, i)
+		}
+	} else {
+		order = append(order, seq...)
+		pos := make([]int, len(bs))
+		for i := range pos {
+			pos[i] = -1
+		}
+		for k, i := range order {
+			pos[i] = k
+		}
+		for i := range bs {
+			if pos[i] >= 0 {
+				continue
+			}
+			k := 0
+			if i > 0 {
+				k = pos[i-1] + 1
+			}
+			order = append(order[:k], append([]int{i}, order[k:]...)...)
+			for k2 := k; k2 < len(order); k2++ {
+				pos[order[k2]] = k2
+			}
+		}
+	}
+	var out Siblings
+	inserted := func(i int) {
+		ons, tns := insO[i], insT[i]
+		switch {
+		case len(tns) == 0 || equalSiblings(ons, tns):
+			out = append(out, deepClones(ons)...)
+		case len(ons) == 0:
+			out = append(out, deepClones(tns)...)
+		default:
+			out = append(out, m.conflict(Conflict{Kind: ConflictInsert, Path: p.child(len(out)),
+				Ours: ons, Theirs: tns})...)
+		}
+	}
+	inserted(-1)
+	for _, i := range order {
+		jo, jt := po[i], pt[i]
+		switch {
+		case jo >= 0 && jt >= 0:
+			out = append(out, m.node(p.child(len(out)), bs[i], os[jo], ts[jt])...)
+		case jo >= 0 && !equal(bs[i], os[jo]):
+			out = append(out, m.conflict(Conflict{Kind: ConflictDelete, Path: p.child(len(out)),
+				Base: Siblings{bs[i]}, Ours: Siblings{os[jo]}})...)
+		case jt >= 0 && !equal(bs[i], ts[jt]):
+			out = append(out, m.conflict(Conflict{Kind: ConflictDelete, Path: p.child(len(out)),
+				Base: Siblings{bs[i]}, Theirs: Siblings{ts[jt]}})...)
+		}
+		inserted(i)
+	}
+	return out
+}
+
+// sequence retrieves the indexes of the matched base nodes in the order of ns
+// and the unmatched nodes of ns by the index of the previous matched base node or -1.
+func sequence(partner []int, ns Siblings) (seq []int, inserted map[int]Siblings) {
+	from := make([]int, len(ns))
+	for j := range from {
+		from[j] = -1
+	}
+	for i, j := range partner {
+		if j >= 0 {
+			from[j] = i
+		}
+	}
+	inserted = map[int]Siblings{}
+	prev := -1
+	for j, n := range ns {
+		if from[j] < 0 {
+			inserted[prev] = append(inserted[prev], n)
+			continue
+		}
+		prev = from[j]
+		seq = append(seq, prev)
+	}
+	return seq, inserted
+}
+
+// sameOrder reports whether the base nodes kept by both sides have the same order in seqO and seqT.
+func sameOrder(seqO, seqT, po, pt []int) bool {
+	var o, t []int
+	for _, i := range seqO {
+		if pt[i] >= 0 {
+			o = append(o, i)
+		}
+	}
+	for _, i := range seqT {
+		if po[i] >= 0 {
+			t = append(t, i)
+		}
+	}
+	for k := range o {
+		if o[k] != t[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// equal reports whether a and b are equal trees.
+func equal(a, b *Node) bool {
+	if a == b {
+		return true
+	}
+	if !similar(a, b) || a.Data != b.Data || !sameAttributes(a.Attributes, b.Attributes) {
+		return false
+	}
+	return equalSiblings(a.Children, b.Children)
+}
+
+// equalSiblings reports whether as and bs contain equal trees.
+func equalSiblings(as, bs Siblings) bool {
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		if !equal(as[i], bs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// deepClones retrieves deep copies of the nodes in s.
+func deepClones(s Siblings) Siblings {
+	c := make(Siblings, len(s))
+	for i, n := range s {
+		c[i] = n.DeepClone()
+	}
+	return c
+}
+
+// siblingsOf retrieves n as Siblings, which are empty if n is nil.
+func siblingsOf(n *Node) Siblings {
+	if n == nil {
+		return nil
+	}
+	return Siblings{n}
+}
@@ -0,0 +1,76 @@
+package hck_test
+
+import (
+	"testing"
+
+	"github.com/arnehormann/hck"
+)
+
+func TestMerge(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		base, ours, theirs string
+		merged, markers    string
+		conflicts          string
+	}{
+		{"both sides", `<p>a</p><p>b</p>`, `<p>a!</p><p>b</p>`, `<p>a</p><p>b!</p>`,
+			`<p>a!</p><p>b!</p>`, "", ""},
+		{"attributes", `<p class=a>x</p>`, `<p class=b>x</p>`, `<p class=a title=t>x</p>`,
+			`<p class=b title=t>x</p>`, "", ""},
+		{"equal inserts", `<p>a</p>`, `<p>a</p><p>o</p>`, `<p>a</p><p>o</p>`,
+			`<p>a</p><p>o</p>`, "", ""},
+		{"delete and insert", `<p>a</p><p>b</p>`, `<p>b</p>`, `<p>a</p><p>b</p><p>c</p>`,
+			`<p>b</p><p>c</p>`, "", ""},
+		{"move and change", `<ul><li id=x>x</li><li id=y>y</li></ul>`,
+			`<ul><li id=y>y</li><li id=x>x</li></ul>`, `<ul><li id=x>x2</li><li id=y>y</li></ul>`,
+			`<ul><li id=y>y</li><li id=x>x2</li></ul>`, "", ""},
+		{"attribute conflict", `<p class=a>x</p>`, `<p class=b>x</p>`, `<p class=c>x</p>`,
+			`<p class=b>x</p>`, "", "attribute conflict /0/1/0 class\n"},
+		{"data conflict", `<p>a</p>`, `<p>b</p>`, `<p>c</p>`,
+			`<p>b</p>`, `<p><merge-conflict><merge-ours>b</merge-ours><merge-theirs>c</merge-theirs></merge-conflict></p>`,
+			"data conflict /0/1/0/0\n"},
+		{"insert conflict", `<p>a</p>`, `<p>a</p><p>o</p>`, `<p>a</p><p>t</p>`,
+			`<p>a</p><p>o</p>`,
+			`<p>a</p><merge-conflict><merge-ours><p>o</p></merge-ours><merge-theirs><p>t</p></merge-theirs></merge-conflict>`,
+			"insert conflict /0/1/1\n"},
+		{"delete conflict", `<p>a</p><p>b</p>`, `<p>b</p>`, `<p>a!</p><p>b</p>`,
+			`<p>b</p>`, `<merge-conflict><merge-ours></merge-ours><merge-theirs><p>a!</p></merge-theirs></merge-conflict><p>b</p>`,
+			"delete conflict /0/1/0\n"},
+		{"move conflict", `<ul><li id=x>x</li><li id=y>y</li><li id=z>z</li></ul>`,
+			`<ul><li id=y>y</li><li id=x>x</li><li id=z>z</li></ul>`, `<ul><li id=x>x</li><li id=z>z</li><li id=y>y</li></ul>`,
+			`<ul><li id=y>y</li><li id=x>x</li><li id=z>z</li></ul>`, "", "move conflict /0/1/0\n"},
+	} {
+		base, ours, theirs := parse(t, tc.base), parse(t, tc.ours), parse(t, tc.theirs)
+		orig := render(t, base) + render(t, ours) + render(t, theirs)
+		m, cs := hck.Merge(base, ours, theirs)
+		if got, want := render(t, m), render(t, parse(t, tc.merged)); got != want {
+			t.Errorf("%s: merged to %s, want %s", tc.name, got, want)
+		}
+		if cs.String() != tc.conflicts {
+			t.Errorf("%s: got conflicts %q, want %q", tc.name, cs, tc.conflicts)
+		}
+		if tc.markers == "" {
+			tc.markers = tc.merged
+		}
+		m, _ = hck.MergeWithOptions(base, ours, theirs, hck.MergeOptions{Markers: true})
+		if got, want := render(t, m), render(t, parse(t, tc.markers)); got != want {
+			t.Errorf("%s: merged with markers to %s, want %s", tc.name, got, want)
+		}
+		if render(t, base)+render(t, ours)+render(t, theirs) != orig {
+			t.Errorf("%s: Merge modified its arguments", tc.name)
+		}
+	}
+}
+
+func TestMergeRoot(t *testing.T) {
+	m, cs := hck.Merge(tag("p"), tag("div"), tag("span"))
+	if got := render(t, m); got != "<div></div>" {
+		t.Errorf("got %s, want <div></div>", got)
+	}
+	if len(cs) != 1 || cs[0].Kind != hck.ConflictReplace {
+		t.Errorf("got conflicts %q, want one replace conflict", cs)
+	}
+	if m, cs := hck.Merge(tag("p"), tag("p"), nil); m != nil || len(cs) != 0 {
+		t.Errorf("deleted root: got %v, %q", m, cs)
+	}
+}
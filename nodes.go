@@ -19,15 +19,15 @@ func index(ns []*Node, m Matcher) int {
 
 // splice copies ns, modifies it and retrieves the copy.
 // Starting at i, del nodes are replaced with n.
+// If the range is invalid, nil is returned.
 func splice(ns []*Node, i, del int, n ...*Node) []*Node {
-	if del < 0 {
+	if del < 0 || i < 0 || i+del > len(ns) {
 		return nil
 	}
 	dest := make([]*Node, len(ns)+len(n)-del)
-	si := i
-	di := copy(dest[:si], ns[:si])
-	di += del - copy(dest[di:], n)
-	di += copy(dest[di:], ns[si:])
+	di := copy(dest, ns[:i])
+	di += copy(dest[di:], n)
+	copy(dest[di:], ns[i+del:])
 	return dest
 }
 
@@ -80,13 +80,13 @@ func (s Siblings) convert(parent *html.Node) (first, last *html.Node) {
 // Render nodes to a writer.
 // nil nodes are skipped.
 func (s Siblings) Render(w io.Writer) error {
-	doc := &html.Node{
-		Type: html.DocumentNode,
-	}
-	first, last := s.convert(doc)
-	doc.FirstChild = first
-	doc.LastChild = last
-	return html.Render(w, doc)
+	return render(w, RenderOptions{}, s...)
+}
+
+// RenderWithOptions renders nodes to a writer like Render,
+// the output is formatted according to opts.
+func (s Siblings) RenderWithOptions(w io.Writer, opts RenderOptions) error {
+	return render(w, opts, s...)
 }
 
 // SplitBefore retrieves siblings up to and starting with the first node from which a match is reachable.
@@ -112,30 +112,51 @@ type Node struct {
 }
 
 // Parse a tree from r.
+// The input is parsed by golang.org/x/net/html, its tree is converted iteratively.
 func Parse(r io.Reader) (*Node, error) {
-	dom, err := html.Parse(r)
+	return ParseWithOptions(r, ParseOptions{})
+}
+
+// ParseFragment parses a fragment of HTML from r in the context of an element.
+// If context is nil, it is parsed in the context of a <body> element.
+// If context is a document node, a whole document is parsed and its children are retrieved.
+// Only the tag and namespace of context are used, it is not modified.
+func ParseFragment(r io.Reader, context *Node) (Siblings, error) {
+	if context == nil {
+		context = Tag("body").Node()
+	}
+	if context.Type == html.DocumentNode {
+		doc, err := Parse(r)
+		if err != nil {
+			return nil, err
+		}
+		return doc.Children, nil
+	}
+	h := &html.Node{
+		Type:      context.Type,
+		Namespace: context.Namespace,
+	}
+	h.DataAtom, h.Data = atomize(context.Data)
+	hs, err := html.ParseFragment(r, h)
 	if err != nil {
 		return nil, err
 	}
-	return Convert(dom), nil
+	ns := make(Siblings, len(hs))
+	for i, h := range hs {
+		ns[i] = Convert(h)
+	}
+	return ns, nil
 }
 
 // Convert a /x/net/html.Node to a Node.
+// The conversion is iterative, deep trees do not exhaust the stack.
 func Convert(h *html.Node) *Node {
-	var children Siblings
-	for c := h.FirstChild; c != nil; c = c.NextSibling {
-		children = append(children, Convert(c))
-	}
-	return &Node{
-		Children:   children,
-		Namespace:  h.Namespace,
-		Data:       h.Data,
-		Attributes: Attributes(h.Attr),
-		Type:       h.Type,
-	}
+	n, _ := convertTree(h, nil)
+	return n
 }
 
 // Clone retrieves a copy of the node.
+// The children are not copied and stay shared, see DeepClone.
 func (n *Node) Clone() *Node {
 	if n == nil {
 		return n
@@ -149,6 +170,65 @@ func (n *Node) Clone() *Node {
 	}
 }
 
+// DeepClone retrieves a copy of the node and all its descendants.
+// Nodes reachable on several paths are copied for each of them.
+// The tree must not contain cycles, see HasCycle.
+func (n *Node) DeepClone() *Node {
+	return n.deepClone(nil)
+}
+
+// DeepCloneShared is like DeepClone but nodes reachable on several paths
+// are copied once and stay shared in the copy. Cycles are copied, too.
+func (n *Node) DeepCloneShared() *Node {
+	return n.deepClone(make(map[*Node]*Node))
+}
+
+func (n *Node) deepClone(memo map[*Node]*Node) *Node {
+	if n == nil {
+		return nil
+	}
+	if c, ok := memo[n]; ok {
+		return c
+	}
+	c := &Node{
+		Namespace:  n.Namespace,
+		Data:       n.Data,
+		Attributes: append(Attributes(nil), n.Attributes...),
+		Type:       n.Type,
+	}
+	if memo != nil {
+		memo[n] = c
+	}
+	if n.Children != nil {
+		c.Children = make(Siblings, len(n.Children))
+		for i, ch := range n.Children {
+			c.Children[i] = ch.deepClone(memo)
+		}
+	}
+	return c
+}
+
+// Shared retrieves the paths to nodes which were already reached on another path.
+// The first path to a node is not included, the descendants of shared nodes are skipped.
+// Nodes which are their own ancestors are reported, too.
+func (n *Node) Shared() []Path {
+	var shared []Path
+	seen := make(map[*Node]bool)
+	n.Walk(WalkFuncs{OnEnter: func(c *Cursor) WalkAction {
+		m := c.Node()
+		if m == nil {
+			return SkipChildren
+		}
+		if seen[m] {
+			shared = append(shared, c.Path())
+			return SkipChildren
+		}
+		seen[m] = true
+		return Continue
+	}})
+	return shared
+}
+
 // Swap state with another node and retrieve that node.
 func (n *Node) Swap(n2 *Node) *Node {
 	n.Children, n2.Children = n2.Children, n.Children
@@ -171,18 +251,20 @@ func (n *Node) convert() *html.Node {
 	}
 	// normalize strings
 	h.DataAtom, h.Data = atomize(n.Data)
-	n.Attributes.atomize()
-	h.Attr = []html.Attribute(n.Attributes)
+	if len(n.Attributes) > 0 {
+		h.Attr = append([]html.Attribute{}, n.Attributes...)
+		Attributes(h.Attr).atomize()
+	}
 	// add children
 	h.FirstChild, h.LastChild = n.Children.convert(h)
 	return h
 }
 
 // Convert a Node to a /x/net/html.Node.
-// If a child node is an ancestor of its own parent, an error will be returned.
+// If a node is its own ancestor, a *CycleError is returned.
 func (n *Node) Convert() (*html.Node, error) {
-	if n.HasCycle() {
-		return nil, loopError{}
+	if err := n.checkCycle(); err != nil {
+		return nil, err
 	}
 	return n.convert(), nil
 }
@@ -218,58 +300,84 @@ func (n *Node) Match(m *Node) bool {
 	return n == m
 }
 
+// Render the node and its descendants to a writer.
+// The nodes are serialized directly, they are neither converted nor modified.
+// If a node is its own ancestor, a *CycleError is returned.
 func (n *Node) Render(w io.Writer) error {
-	doc, err := n.Convert()
-	if err != nil {
+	return render(w, RenderOptions{}, n)
+}
+
+// RenderWithOptions renders the node like Render,
+// the output is formatted according to opts.
+func (n *Node) RenderWithOptions(w io.Writer, opts RenderOptions) error {
+	return render(w, opts, n)
+}
+
+// RenderValid validates the tree and renders it if it has no content model violations.
+// Violations are reported as *ValidationError.
+func (n *Node) RenderValid(w io.Writer) error {
+	if err := n.checkCycle(); err != nil {
 		return err
 	}
-	if n.Type != html.DocumentNode {
-		tmp := &html.Node{
-			Type:       html.DocumentNode,
-			FirstChild: doc,
-			LastChild:  doc,
-		}
-		doc.Parent = tmp
-		doc = tmp
+	if vs := n.Validate(); len(vs) > 0 {
+		return &ValidationError{Violations: vs}
 	}
-	return html.Render(w, doc)
+	return n.Render(w)
 }
 
-// HasCycle reports whether any reachable node is the ancestor of its own parents.
+// HasCycle reports whether any reachable node is its own ancestor.
 func (n *Node) HasCycle() bool {
-	return n.hasCycle(nil, make(map[*Node][]*Node))
+	return n.Cycle() != nil
 }
 
-func (n *Node) hasCycle(parent *Node, parents map[*Node][]*Node) bool {
-	if n == nil {
-		return false
-	}
-	known := parents[n]
-	parents[n] = append(known, parent)
-	check := []*Node{n}
-	for next := check; len(next) > 0; {
-		for _, c := range check {
-			if c == nil {
-				continue
+// Cycle retrieves a path from n to a node which is its own ancestor.
+// The last node of the path is also contained earlier in it.
+// If the tree has no cycles, nil is returned.
+// Nodes reachable on several paths are only searched once.
+func (n *Node) Cycle() Path {
+	const (
+		open = iota + 1
+		done
+	)
+	var cycle Path
+	state := make(map[*Node]int)
+	n.Walk(WalkFuncs{
+		OnEnter: func(c *Cursor) WalkAction {
+			m := c.Node()
+			switch {
+			case m == nil, state[m] == done:
+				return SkipChildren
+			case state[m] == open:
+				cycle = c.Path()
+				return Stop
 			}
-			for _, k := range known {
-				if k == nil {
-					continue
-				}
-				if k == n {
-					return true
-				}
+			state[m] = open
+			return Continue
+		},
+		OnLeave: func(c *Cursor) WalkAction {
+			if m := c.Node(); m != nil && state[m] == open {
+				state[m] = done
 			}
-			next = append(next, c.Children...)
-		}
-		check = append(check[:0:0], next...)
-		next = next[:0]
+			return Continue
+		},
+	})
+	return cycle
+}
+
+// checkCycle retrieves a *CycleError if n has a cycle.
+func (n *Node) checkCycle() error {
+	if p := n.Cycle(); p != nil {
+		return &CycleError{Path: p}
 	}
-	return false
+	return nil
 }
 
-type loopError struct{}
+// CycleError is reported for trees containing a node which is its own ancestor.
+type CycleError struct {
+	// Path to the repeated node, its last node is also contained earlier
+	Path Path
+}
 
-func (e loopError) Error() string {
-	return "graph contains loops"
+func (e *CycleError) Error() string {
+	return "cycle in tree at " + e.Path.String()
 }
@@ -0,0 +1,68 @@
+package hck
+
+import (
+	"bytes"
+
+	"golang.org/x/net/html"
+)
+
+// Normalize rewrites the children of n into the shape the HTML parser would create
+// when reading the rendered tree back.
+// Implied elements are inserted, misnested elements are moved
+// and adjacent text nodes are merged.
+//
+// Documents are parsed as a whole, so head elements are hoisted and
+// html, head and body elements are added where they are missing.
+// The children of other elements are parsed as a fragment in the context of n,
+// elements containing raw text like <script> and <textarea> are parsed with their tags.
+// n itself is not changed, use Normalize on its parent if it is misplaced.
+func (n *Node) Normalize() error {
+	if n == nil {
+		return nil
+	}
+	if err := n.checkCycle(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	switch n.Type {
+	case html.DocumentNode:
+		if err := n.Render(&buf); err != nil {
+			return err
+		}
+		doc, err := Parse(&buf)
+		if err != nil {
+			return err
+		}
+		n.Children = doc.Children
+	case html.ElementNode:
+		if textContent(n) {
+			// the children are only tokenized as text after the start tag of n
+			if err := n.Render(&buf); err != nil {
+				return err
+			}
+			ns, err := ParseFragment(&buf, nil)
+			if err != nil {
+				return err
+			}
+			if len(ns) == 1 && isTag(ns[0], n.Data) {
+				n.Children = ns[0].Children
+			}
+			return nil
+		}
+		if err := n.Children.Render(&buf); err != nil {
+			return err
+		}
+		children, err := ParseFragment(&buf, n)
+		if err != nil {
+			return err
+		}
+		n.Children = children
+	}
+	return nil
+}
+
+// textContent reports whether the parser reads the content of n as raw or escapable raw text.
+func textContent(n *Node) bool {
+	return isTag(n, "script", "style", "xmp", "iframe", "noembed", "noframes", "noscript",
+		"plaintext", "textarea", "title")
+}
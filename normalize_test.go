@@ -0,0 +1,74 @@
+package hck_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arnehormann/hck"
+)
+
+// tag creates an element with children.
+func tag(name string, children ...*hck.Node) *hck.Node {
+	n := hck.Tag(name).Node()
+	n.Children = children
+	return n
+}
+
+// render retrieves the HTML of n.
+func render(t *testing.T, n *hck.Node) string {
+	t.Helper()
+	var b strings.Builder
+	if err := n.Render(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		n    *hck.Node
+		html string
+	}{
+		{"merged text", tag("p", hck.Text("a"), hck.Text("b")), "<p>ab</p>"},
+		{"implied tbody", tag("table", tag("tr", tag("td", hck.Text("a")))),
+			"<table><tbody><tr><td>a</td></tr></tbody></table>"},
+		{"misnested block", tag("div", tag("p", tag("div"))), "<div><p></p><div></div><p></p></div>"},
+		{"script", tag("script", hck.Text("if (a < b && c) {}")), "<script>if (a < b && c) {}</script>"},
+		{"style", tag("style", hck.Text("a > b {}"), hck.Text(" p {}")), "<style>a > b {} p {}</style>"},
+		{"textarea", tag("textarea", hck.Text("\n<b>&amp;</b>")), "<textarea>\n\n&lt;b&gt;&amp;amp;&lt;/b&gt;</textarea>"},
+		{"title", tag("title", hck.Text("a < b")), "<title>a &lt; b</title>"},
+		{"script in div", tag("div", tag("script", hck.Text("a < b"))), "<div><script>a < b</script></div>"},
+		{"document", hck.Document(tag("title", hck.Text("t")), tag("p", tag("div"))),
+			"<html><head><title>t</title></head><body><p></p><div></div><p></p></body></html>"},
+	} {
+		if err := tc.n.Normalize(); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got := render(t, tc.n); got != tc.html {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.html)
+		}
+		// normalized trees are stable
+		want := render(t, tc.n)
+		if err := tc.n.Normalize(); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if got := render(t, tc.n); got != want {
+			t.Errorf("%s: normalized again to %s, want %s", tc.name, got, want)
+		}
+	}
+}
+
+func TestNormalizeRawText(t *testing.T) {
+	for _, name := range []string{"script", "style", "xmp", "iframe", "noembed", "noframes", "noscript", "textarea", "title"} {
+		const text = "if (a < b && c) { x = '&lt;' }"
+		n := tag(name, hck.Text(text))
+		if err := n.Normalize(); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(n.Children) != 1 || n.Children[0].Data != text {
+			t.Errorf("%s: got children %v, want the text %q", name, n.Children, text)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package hck
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// ParseOptions configure ParseWithOptions.
+// The zero value parses like Parse.
+type ParseOptions struct {
+	// DisableScripting parses the content of <noscript> as markup instead of text,
+	// like browsers with disabled scripts.
+	DisableScripting bool
+
+	// MaxDepth is the maximum number of ancestors of a node, 0 means no limit.
+	MaxDepth int
+
+	// MaxNodes is the maximum number of nodes in the tree, 0 means no limit.
+	MaxNodes int
+
+	// MaxTextSize is the maximum number of bytes in all text and comment nodes, 0 means no limit.
+	MaxTextSize int
+
+	// MaxInputSize is the maximum number of bytes read from the input, 0 means no limit.
+	// It bounds the work and memory of the parser before the other limits are checked.
+	MaxInputSize int
+
+	// DetectCharset transcodes the input to UTF-8.
+	// The charset is determined by a byte order mark, ContentType or a <meta> element
+	// in the first 1024 bytes, windows-1252 is used if none is found and the input is not UTF-8.
+	DetectCharset bool
+
+	// ContentType is the value of a Content-Type header used for charset detection,
+	// e.g. "text/html; charset=iso-8859-1".
+	ContentType string
+}
+
+// LimitError is reported when parsed input exceeds a limit of the ParseOptions.
+type LimitError struct {
+	// Limit is the name of the exceeded limit: "depth", "nodes", "text size" or "input size"
+	Limit string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return "parsed html exceeds maximum " + e.Limit + " of " + strconv.Itoa(e.Max)
+}
+
+// ParseWithOptions parses a tree from r.
+// MaxInputSize is checked while the input is read, reading stops when it is exceeded.
+// MaxDepth, MaxNodes and MaxTextSize are checked while the parsed html.Node tree
+// is converted, so the parser builds its tree before they apply.
+// The size of that tree is roughly proportional to the input,
+// use MaxInputSize to bound it for untrusted input.
+// The tree is not retrieved if a limit is exceeded.
+func ParseWithOptions(r io.Reader, opts ParseOptions) (*Node, error) {
+	if opts.MaxInputSize > 0 {
+		r = &countingReader{r: r, max: opts.MaxInputSize}
+	}
+	if opts.DetectCharset {
+		cr, err := charset.NewReader(r, opts.ContentType)
+		switch {
+		case err == io.EOF:
+			cr = strings.NewReader("")
+		case err != nil:
+			return nil, err
+		}
+		r = cr
+	}
+	dom, err := html.ParseWithOptions(r, html.ParseOptionEnableScripting(!opts.DisableScripting))
+	if err != nil {
+		return nil, err
+	}
+	return convertTree(dom, &opts)
+}
+
+// countingReader reports a LimitError when more than max bytes are read from r.
+type countingReader struct {
+	r      io.Reader
+	n, max int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	if c.n > c.max {
+		return 0, &LimitError{Limit: "input size", Max: c.max}
+	}
+	// read at most one byte more than allowed to detect the excess
+	if rest := c.max - c.n + 1; len(p) > rest {
+		p = p[:rest]
+	}
+	n, err := c.r.Read(p)
+	c.n += n
+	if c.n > c.max {
+		return n, &LimitError{Limit: "input size", Max: c.max}
+	}
+	return n, err
+}
+
+// convertTree converts h and its descendants iteratively.
+// Each node and child slice is allocated on its own, so a retained node
+// does not keep unrelated nodes of the document alive.
+// If opts is not nil, its limits are checked while the nodes are converted.
+func convertTree(h *html.Node, opts *ParseOptions) (*Node, error) {
+	if h == nil {
+		return nil, nil
+	}
+	var count, text int
+	parents := make([]*Node, 0, 8)
+	if err := walkHTML(h, func(n *html.Node, depth int) error {
+		count++
+		if n.Type == html.TextNode || n.Type == html.CommentNode {
+			text += len(n.Data)
+		}
+		if opts != nil {
+			if err := opts.check(depth, count, text); err != nil {
+				return err
+			}
+		}
+		c := &Node{
+			Namespace:  n.Namespace,
+			Data:       n.Data,
+			Attributes: Attributes(n.Attr),
+			Type:       n.Type,
+		}
+		k := 0
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			k++
+		}
+		if k > 0 {
+			c.Children = make(Siblings, 0, k)
+		}
+		parents = parents[:depth]
+		if depth > 0 {
+			p := parents[depth-1]
+			p.Children = append(p.Children, c)
+		}
+		parents = append(parents, c)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return parents[0], nil
+}
+
+// walkHTML calls visit for h and its descendants in document order
+// with the number of ancestors below h.
+// It stops at the first error.
+func walkHTML(h *html.Node, visit func(n *html.Node, depth int) error) error {
+	depth := 0
+	for n := h; ; {
+		if err := visit(n, depth); err != nil {
+			return err
+		}
+		if n.FirstChild != nil {
+			n = n.FirstChild
+			depth++
+			continue
+		}
+		for n != h && n.NextSibling == nil {
+			n = n.Parent
+			depth--
+		}
+		if n == h {
+			return nil
+		}
+		n = n.NextSibling
+	}
+}
+
+// check reports an error if a limit is exceeded by the depth of a node,
+// the number of nodes or the text size.
+func (o *ParseOptions) check(depth, count, text int) error {
+	if o.MaxDepth > 0 && depth > o.MaxDepth {
+		return &LimitError{Limit: "depth", Max: o.MaxDepth}
+	}
+	if o.MaxNodes > 0 && count > o.MaxNodes {
+		return &LimitError{Limit: "nodes", Max: o.MaxNodes}
+	}
+	if o.MaxTextSize > 0 && text > o.MaxTextSize {
+		return &LimitError{Limit: "text size", Max: o.MaxTextSize}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package hck_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/arnehormann/hck"
+	"golang.org/x/net/html"
+)
+
+// benchPage retrieves a page shaped like a large listing or report page:
+// navigation, a long table, nested articles with inline markup, scripts and forms.
+func benchPage() []byte {
+	var b bytes.Buffer
+	b.WriteString(`<!DOCTYPE html><html lang="en"><head><meta charset="utf-8"><title>Report</title>`)
+	b.WriteString(`<link rel="stylesheet" href="/s.css"><style>td{padding:2px}</style>`)
+	b.WriteString(`<script>window.dataLayer=window.dataLayer||[];</script></head><body class="page">`)
+	b.WriteString(`<header><nav><ul>`)
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&b, `<li class="nav-item"><a href="/section/%d" title="Section %d">Section %d</a></li>`, i, i, i)
+	}
+	b.WriteString(`</ul></nav></header><main><table id="data"><thead><tr><th>Id<th>Name<th>Value<th>Link</thead><tbody>`)
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, "<tr class=row%d><td>%d<td><b>Item</b> %d &amp; more<td>%d.%02d<td><a href=\"/item?id=%d\">open</a>\n", i%2, i, i, i*7, i%100, i)
+	}
+	b.WriteString(`</tbody></table>`)
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&b, `<article id="a%d"><h2>Title %d</h2><p>Some <em>text</em> with <a href="#a%d">links</a>, `, i, i, i)
+		b.WriteString(strings.Repeat(`<span class="w">word</span> `, 20))
+		b.WriteString(`<img src="/i.png" alt=""><br>more text<p>Second paragraph<div><div><div><p>nested</div></div></div>`)
+		b.WriteString(`<form action="/f"><input name=q><select><option>a<option selected>b</select><button>go</button></form></article>`)
+	}
+	b.WriteString(`</main><footer><p>&copy; 2024</footer><script>console.log("done")</script></body></html>`)
+	return b.Bytes()
+}
+
+// endless is an endless input repeating its content.
+type endless struct {
+	s    string
+	read int
+}
+
+func (e *endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = e.s[(e.read+i)%len(e.s)]
+	}
+	e.read += len(p)
+	return len(p), nil
+}
+
+func TestParseWithOptions(t *testing.T) {
+	deep := "<p>" + strings.Repeat("<b>", 20) + "x"
+	for _, tc := range []struct {
+		name  string
+		in    string
+		opts  hck.ParseOptions
+		limit string
+		html  string
+	}{
+		{"depth", deep, hck.ParseOptions{MaxDepth: 10}, "depth", ""},
+		{"nodes", deep, hck.ParseOptions{MaxNodes: 10}, "nodes", ""},
+		{"text size", "<p>hello<!--x-->", hck.ParseOptions{MaxTextSize: 5}, "text size", ""},
+		{"input size", "<p>hello", hck.ParseOptions{MaxInputSize: 7}, "input size", ""},
+		{"within limits", "<p>hello", hck.ParseOptions{MaxDepth: 4, MaxNodes: 6, MaxTextSize: 5, MaxInputSize: 8},
+			"", "<html><head></head><body><p>hello</p></body></html>"},
+		{"meta charset", "<meta charset=iso-8859-1><p>\xe4", hck.ParseOptions{DetectCharset: true},
+			"", `<html><head><meta charset="iso-8859-1"/></head><body><p>ä</p></body></html>`},
+		{"content type", "<p>\xe4", hck.ParseOptions{DetectCharset: true, ContentType: "text/html; charset=latin1"},
+			"", "<html><head></head><body><p>ä</p></body></html>"},
+		{"empty charset", "", hck.ParseOptions{DetectCharset: true}, "", "<html><head></head><body></body></html>"},
+		{"scripting", "<noscript><b>x</b></noscript>", hck.ParseOptions{},
+			"", "<html><head><noscript><b>x</b></noscript></head><body></body></html>"},
+		{"disabled scripting", "<p><noscript><b>x</b></noscript>", hck.ParseOptions{DisableScripting: true},
+			"", "<html><head></head><body><p><noscript><b>x</b></noscript></p></body></html>"},
+	} {
+		doc, err := hck.ParseWithOptions(strings.NewReader(tc.in), tc.opts)
+		var le *hck.LimitError
+		switch {
+		case tc.limit != "":
+			if !errors.As(err, &le) || le.Limit != tc.limit {
+				t.Errorf("%s: got error %v, want a LimitError for %s", tc.name, err, tc.limit)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		default:
+			if got := render(t, doc); got != tc.html {
+				t.Errorf("%s: got %s, want %s", tc.name, got, tc.html)
+			}
+		}
+	}
+}
+
+func TestParseStopsReading(t *testing.T) {
+	in := &endless{s: "<p>text "}
+	_, err := hck.ParseWithOptions(in, hck.ParseOptions{MaxInputSize: 1 << 16})
+	var le *hck.LimitError
+	if !errors.As(err, &le) || le.Limit != "input size" {
+		t.Fatalf("got error %v, want a LimitError for input size", err)
+	}
+	if in.read > 1<<16+1 {
+		t.Errorf("read %d bytes, want at most %d", in.read, 1<<16+1)
+	}
+}
+
+// BenchmarkHTMLParse measures the parser of golang.org/x/net/html alone,
+// Parse and ParseWithLimits add the conversion to it.
+func BenchmarkHTMLParse(b *testing.B) {
+	page := benchPage()
+	b.SetBytes(int64(len(page)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := html.Parse(bytes.NewReader(page)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	page := benchPage()
+	b.SetBytes(int64(len(page)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := hck.Parse(bytes.NewReader(page)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseWithLimits(b *testing.B) {
+	page := benchPage()
+	opts := hck.ParseOptions{MaxDepth: 512, MaxNodes: 1 << 20, MaxTextSize: 1 << 24, MaxInputSize: 1 << 24}
+	b.SetBytes(int64(len(page)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := hck.ParseWithOptions(bytes.NewReader(page), opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// convertRecursive is the recursive conversion Convert replaced.
+func convertRecursive(h *html.Node) *hck.Node {
+	var children hck.Siblings
+	for c := h.FirstChild; c != nil; c = c.NextSibling {
+		children = append(children, convertRecursive(c))
+	}
+	return &hck.Node{
+		Children:   children,
+		Namespace:  h.Namespace,
+		Data:       h.Data,
+		Attributes: hck.Attributes(h.Attr),
+		Type:       h.Type,
+	}
+}
+
+func BenchmarkConvert(b *testing.B) {
+	dom, err := html.Parse(bytes.NewReader(benchPage()))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hck.Convert(dom)
+	}
+}
+
+func BenchmarkConvertRecursive(b *testing.B) {
+	dom, err := html.Parse(bytes.NewReader(benchPage()))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		convertRecursive(dom)
+	}
+}
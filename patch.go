@@ -0,0 +1,182 @@
+package hck
+
+import (
+	"strconv"
+)
+
+// PatchError is reported when an edit can not be applied.
+type PatchError struct {
+	// Index of the failing edit
+	Index int
+	Edit  Edit
+	Msg   string
+}
+
+func (e *PatchError) Error() string {
+	return "patch edit " + strconv.Itoa(e.Index) + " (" + e.Edit.String() + "): " + e.Msg
+}
+
+// Patch applies the edits to the tree in root.
+// Each edit checks that the tree still contains the expected old values and nodes,
+// nodes are compared by type, tag and id.
+// Inserted nodes are deep copies of the nodes in the edits.
+// A root replacement swaps the content of root with the new node.
+//
+// If an edit fails, the applied edits are reverted and a *PatchError is returned.
+func Patch(root *Node, es Edits) error {
+	undo := make(Edits, 0, len(es))
+	for i, e := range es {
+		u, msg := apply(root, e, true)
+		if msg != "" {
+			for j := len(undo) - 1; j >= 0; j-- {
+				apply(root, undo[j], false)
+			}
+			return &PatchError{Index: i, Edit: e, Msg: msg}
+		}
+		undo = append(undo, u)
+	}
+	return nil
+}
+
+// Invert retrieves the edits reverting es.
+func (es Edits) Invert() Edits {
+	inv := make(Edits, len(es))
+	for i, e := range es {
+		inv[len(es)-1-i] = e.Invert()
+	}
+	return inv
+}
+
+// Invert retrieves the edit reverting e.
+func (e Edit) Invert() Edit {
+	inv := e
+	inv.OldNode, inv.NewNode = e.NewNode, e.OldNode
+	inv.Old, inv.New = e.New, e.Old
+	switch e.Op {
+	case InsertNode:
+		inv.Op = DeleteNode
+	case DeleteNode:
+		inv.Op = InsertNode
+	case MoveNode:
+		inv.Path, inv.From = e.From, e.Path
+	case AddAttr:
+		inv.Op = RemoveAttr
+	case RemoveAttr:
+		inv.Op = AddAttr
+	}
+	return inv
+}
+
+// at retrieves the node at p and its parent.
+// If p does not exist, n is nil.
+func (n *Node) at(p IndexPath) (parent, node *Node) {
+	node = n
+	for _, i := range p {
+		if node == nil || i < 0 || i >= len(node.Children) {
+			return nil, nil
+		}
+		parent, node = node, node.Children[i]
+	}
+	return parent, node
+}
+
+// apply applies e to the tree in root and retrieves the edit reverting it.
+// Inserted nodes are copied if clone is set.
+// If e can not be applied, the tree is not changed and the reason is returned.
+func apply(root *Node, e Edit, clone bool) (undo Edit, msg string) {
+	undo = e.Invert()
+	insert := e.NewNode
+	if clone && (e.Op == InsertNode || e.Op == ReplaceNode) {
+		insert = insert.DeepClone()
+	}
+	parent, n := root.at(e.Path)
+	switch e.Op {
+	case InsertNode:
+		if len(e.Path) == 0 {
+			return undo, "can not insert a root"
+		}
+		_, parent = root.at(e.Path[:len(e.Path)-1])
+		i := e.Path[len(e.Path)-1]
+		if parent == nil || i < 0 || i > len(parent.Children) {
+			return undo, "no parent or index out of range"
+		}
+		parent.Children = parent.Children.Splice(i, 0, insert)
+		undo.OldNode = insert
+		return undo, ""
+	case DeleteNode, MoveNode, ReplaceNode:
+		from := e.Path
+		if e.Op == MoveNode {
+			from = e.From
+			parent, n = root.at(from)
+		}
+		if len(from) > 0 && parent == nil {
+			return undo, "node not found"
+		}
+		if e.OldNode != nil && !similar(n, e.OldNode) {
+			return undo, "found " + describe(n)
+		}
+		switch e.Op {
+		case DeleteNode:
+			if parent == nil {
+				return undo, "can not delete the root"
+			}
+			parent.Children = parent.Children.Splice(from[len(from)-1], 1)
+			undo.NewNode = n
+		case ReplaceNode:
+			if parent == nil {
+				if insert == nil {
+					return undo, "can not replace the root with nothing"
+				}
+				// swap the content of root, the new root node keeps the old content
+				undo.OldNode = root
+				undo.NewNode = root.Swap(insert)
+				return undo, ""
+			}
+			parent.Children = parent.Children.Splice(from[len(from)-1], 1, insert)
+			undo.OldNode, undo.NewNode = insert, n
+		case MoveNode:
+			if parent == nil || len(e.Path) == 0 {
+				return undo, "can not move the root"
+			}
+			rest := parent.Children.Splice(from[len(from)-1], 1)
+			old := parent.Children
+			parent.Children = rest
+			_, to := root.at(e.Path[:len(e.Path)-1])
+			i := e.Path[len(e.Path)-1]
+			if to == nil || i < 0 || i > len(to.Children) {
+				parent.Children = old
+				return undo, "no target parent or index out of range"
+			}
+			to.Children = to.Children.Splice(i, 0, n)
+		}
+		return undo, ""
+	}
+	if n == nil {
+		return undo, "node not found"
+	}
+	switch e.Op {
+	case AddAttr, SetAttr, RemoveAttr:
+		a := n.Attribute(e.Key, e.Namespace)
+		switch {
+		case e.Op == AddAttr && a != nil:
+			return undo, "attribute exists with " + strconv.Quote(a.Val)
+		case e.Op != AddAttr && a == nil:
+			return undo, "attribute not found"
+		case e.Op != AddAttr && a.Val != e.Old:
+			return undo, "attribute is " + strconv.Quote(a.Val)
+		}
+		if e.Op == RemoveAttr {
+			n.Attributes.Delete(a)
+		} else {
+			n.SetAttrNS(e.Key, e.Namespace, e.New)
+		}
+	case SetData:
+		if n.Data != e.Old {
+			return undo, "data is " + strconv.Quote(n.Data)
+		}
+		n.Data = e.New
+	default:
+		return undo, "unknown operation"
+	}
+	return undo, ""
+}
@@ -0,0 +1,67 @@
+package hck_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arnehormann/hck"
+)
+
+func TestPatchError(t *testing.T) {
+	p := func() *hck.Node { return tag("p", hck.Text("a"), tag("b")) }
+	for _, tc := range []struct {
+		name  string
+		edits hck.Edits
+		index int
+	}{
+		{"replace root with nothing", hck.Diff(p(), nil), 0},
+		{"delete root", hck.Edits{{Op: hck.DeleteNode, Path: hck.IndexPath{}}}, 0},
+		{"insert root", hck.Edits{{Op: hck.InsertNode, Path: hck.IndexPath{}, NewNode: tag("i")}}, 0},
+		{"move root", hck.Edits{{Op: hck.MoveNode, Path: hck.IndexPath{}, From: hck.IndexPath{}}}, 0},
+		{"missing node", hck.Edits{{Op: hck.DeleteNode, Path: hck.IndexPath{5}}}, 0},
+		{"insert out of range", hck.Edits{{Op: hck.InsertNode, Path: hck.IndexPath{3}, NewNode: tag("i")}}, 0},
+		{"other node", hck.Edits{
+			{Op: hck.InsertNode, Path: hck.IndexPath{0}, NewNode: tag("i")},
+			{Op: hck.DeleteNode, Path: hck.IndexPath{1}, OldNode: tag("b")},
+		}, 1},
+		{"other data", hck.Edits{
+			{Op: hck.ReplaceNode, Path: hck.IndexPath{1}, OldNode: tag("b"), NewNode: tag("u")},
+			{Op: hck.SetData, Path: hck.IndexPath{0}, Old: "x", New: "y"},
+		}, 1},
+		{"applied twice", append(hck.Diff(p(), tag("p", tag("b"))), hck.Diff(p(), tag("p", tag("b")))...), 1},
+	} {
+		n := p()
+		want := render(t, n)
+		err := hck.Patch(n, tc.edits)
+		var pe *hck.PatchError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: got error %v, want a PatchError", tc.name, err)
+			continue
+		}
+		if pe.Index != tc.index {
+			t.Errorf("%s: failed at edit %d, want %d: %v", tc.name, pe.Index, tc.index, err)
+		}
+		if got := render(t, n); got != want {
+			t.Errorf("%s: got %s after the failed patch, want %s", tc.name, got, want)
+		}
+	}
+}
+
+func TestPatchRoot(t *testing.T) {
+	a, b := hck.Text("x"), tag("p", hck.Text("y"))
+	es := hck.Diff(a, b)
+	// edits refer to the nodes of a, they are not patched
+	n := a.DeepClone()
+	if err := hck.Patch(n, es); err != nil {
+		t.Fatal(err)
+	}
+	if got := render(t, n); got != "<p>y</p>" {
+		t.Errorf("got %s, want <p>y</p>", got)
+	}
+	if err := hck.Patch(n, es.Invert()); err != nil {
+		t.Fatal(err)
+	}
+	if got := render(t, n); got != "x" {
+		t.Errorf("inverted to %s, want x", got)
+	}
+}
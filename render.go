@@ -0,0 +1,754 @@
+package hck
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type writer interface {
+	io.Writer
+	io.ByteWriter
+	WriteString(string) (int, error)
+}
+
+// RenderOptions configure RenderWithOptions.
+// The zero value renders like Render.
+type RenderOptions struct {
+	// Indent enables pretty printing.
+	// Children of elements only containing block level elements, comments
+	// and whitespace are written on separate lines, indented by Indent per level.
+	// Other content is written as is.
+	Indent string
+
+	// Minify removes comments and optional tags, collapses whitespace
+	// and writes safe attribute values without quotes.
+	// Optional end tags are only removed where the parser closes the element
+	// in the same place, also in trees the parser would not create.
+	// Void elements end with > instead of />.
+	// Indent is ignored.
+	Minify bool
+
+	// XHTML writes polyglot markup which can also be parsed as XML.
+	// Empty foreign elements are closed like void elements,
+	// namespaces are declared on the root and foreign elements
+	// and attribute values are always quoted.
+	// Optional tags are not removed.
+	XHTML bool
+
+	// Canonical writes attributes sorted by namespace, key and value
+	// and separates classes by single spaces.
+	// Together with the fixed escaping of text and attribute values,
+	// equivalent trees are written identically.
+	Canonical bool
+}
+
+// render writes the nodes to w following the HTML serialization algorithm
+// like html.Render does for converted nodes.
+// The nodes are not modified.
+func render(w io.Writer, opts RenderOptions, ns ...*Node) error {
+	ww, ok := w.(writer)
+	var buf *bufio.Writer
+	if !ok {
+		buf = bufio.NewWriter(w)
+		ww = buf
+	}
+	if opts.Minify {
+		opts.Indent = ""
+	}
+	r := &renderer{w: ww, opts: opts}
+	r.frames = append(r.frames, frame{block: r.blockChildren(true, false, ns)})
+	for _, n := range ns {
+		if n == nil {
+			continue
+		}
+		if !n.Walk(r) {
+			break
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if buf != nil {
+		return buf.Flush()
+	}
+	return nil
+}
+
+// renderer is a Visitor writing the visited nodes.
+// The first error is kept and stops the walk.
+type renderer struct {
+	w    writer
+	err  error
+	opts RenderOptions
+
+	// frames of the nodes being rendered and their parent
+	frames []frame
+
+	// indentation level
+	level int
+
+	// number of open elements preserving whitespace
+	preserve int
+
+	// whether anything was written
+	started bool
+
+	// whether the last written byte is a collapsed space of a text node
+	space bool
+
+	// sorted attributes in canonical output
+	attrs Attributes
+}
+
+// frame is the state of a node being rendered.
+type frame struct {
+	// children are block level and written on separate lines
+	block bool
+	// a child was written on a new line
+	lines bool
+	// the end tag has to be written
+	open bool
+}
+
+func (r *renderer) str(s string) {
+	r.space = false
+	if r.err == nil {
+		_, r.err = r.w.WriteString(s)
+	}
+}
+
+func (r *renderer) byte(b byte) {
+	r.space = false
+	if r.err == nil {
+		r.err = r.w.WriteByte(b)
+	}
+}
+
+func (r *renderer) action(a WalkAction) WalkAction {
+	if r.err != nil {
+		return Stop
+	}
+	return a
+}
+
+// newline starts a new indented line if anything was written before.
+func (r *renderer) newline() {
+	if !r.started {
+		return
+	}
+	r.byte('\n')
+	for i := 0; i < r.level; i++ {
+		r.str(r.opts.Indent)
+	}
+}
+
+func (r *renderer) Enter(c *Cursor) WalkAction {
+	n := c.Node()
+	parent := &r.frames[len(r.frames)-1]
+	if n == nil || r.removed(n, parent.block) {
+		r.frames = append(r.frames, frame{})
+		return SkipChildren
+	}
+	if len(n.Children) > 0 {
+		for _, a := range c.path[:len(c.path)-1] {
+			if a == n {
+				r.err = &CycleError{Path: c.Path()}
+				return Stop
+			}
+		}
+	}
+	block := parent.block
+	if block && r.opts.Indent != "" {
+		parent.lines = true
+		r.newline()
+	}
+	r.frames = append(r.frames, frame{})
+	f := &r.frames[len(r.frames)-1]
+	switch n.Type {
+	case html.ErrorNode:
+		r.err = errors.New("html: cannot render an ErrorNode node")
+	case html.TextNode:
+		switch p := parentElement(c.path); {
+		case p >= 0 && literalChildText(c.path[:p+1]):
+			r.str(n.Data)
+		case r.opts.Minify && r.preserve == 0:
+			// adjacent text nodes are parsed as one, their spaces are collapsed together
+			s, space := collapseSpace(n.Data), r.space
+			if space && strings.HasPrefix(s, " ") {
+				s = s[1:]
+			}
+			r.escape(s)
+			r.space = space && s == "" || strings.HasSuffix(s, " ")
+		default:
+			r.escape(n.Data)
+		}
+	case html.DocumentNode:
+		f.block = r.blockChildren(block, false, n.Children)
+		return r.action(Continue)
+	case html.ElementNode:
+		r.started = true
+		f.block = r.blockChildren(block, n.Namespace != "", n.Children) && !preserveSpace(n)
+		return r.action(r.startTag(c, f))
+	case html.CommentNode:
+		r.str("<!--")
+		r.escapeComment(n.Data)
+		r.str("-->")
+	case html.DoctypeNode:
+		r.doctype(n)
+	case html.RawNode:
+		r.str(n.Data)
+	default:
+		r.err = errors.New("html: unknown node type")
+	}
+	r.started = true
+	return r.action(SkipChildren)
+}
+
+func (r *renderer) Leave(c *Cursor) WalkAction {
+	f := r.frames[len(r.frames)-1]
+	r.frames = r.frames[:len(r.frames)-1]
+	if !f.open {
+		return r.action(Continue)
+	}
+	n := c.Node()
+	if n.Data == "plaintext" && literalChildText(c.path) {
+		// nothing after <plaintext> can be parsed as markup
+		return Stop
+	}
+	if preserveSpace(n) {
+		r.preserve--
+	}
+	if f.block {
+		r.level--
+	}
+	if f.lines {
+		r.newline()
+	}
+	if r.opts.Minify && !r.opts.XHTML && r.omitEndTag(c) {
+		return r.action(Continue)
+	}
+	r.str("</")
+	r.str(n.Data)
+	r.byte('>')
+	return r.action(Continue)
+}
+
+func (r *renderer) startTag(c *Cursor, f *frame) WalkAction {
+	n := c.Node()
+	if !r.opts.Minify || r.opts.XHTML || !r.omitStartTag(n, f.block) {
+		r.byte('<')
+		r.str(n.Data)
+		r.attributes(c)
+		if voidElements[n.Data] {
+			for _, ch := range n.Children {
+				if ch != nil {
+					r.err = errors.New("html: void element <" + n.Data + "> has child nodes")
+					return Stop
+				}
+			}
+			if r.opts.Minify && !r.opts.XHTML {
+				// a slash would belong to an unquoted attribute value
+				r.byte('>')
+			} else {
+				r.str("/>")
+			}
+			return SkipChildren
+		}
+		if r.opts.XHTML && n.Namespace != "" && firstChild(n) == nil {
+			r.str("/>")
+			return SkipChildren
+		}
+		r.byte('>')
+	}
+	f.open = true
+	if f.block {
+		r.level++
+	}
+	if preserveSpace(n) {
+		r.preserve++
+	}
+	switch n.Data {
+	case "pre", "listing", "textarea":
+		// the parser drops a newline directly after the start tag
+		if ch := firstChild(n); ch != nil && ch.Type == html.TextNode && strings.HasPrefix(ch.Data, "\n") {
+			r.byte('\n')
+		}
+	}
+	return Continue
+}
+
+func (r *renderer) attributes(c *Cursor) {
+	n := c.Node()
+	attrs := n.Attributes
+	if r.opts.Canonical {
+		r.attrs = append(r.attrs[:0], attrs...)
+		sort.Sort(r.attrs)
+		attrs = r.attrs
+	}
+	for _, a := range attrs {
+		if r.opts.Canonical && a.Namespace == "" && a.Key == attrClass {
+			a.Val = strings.Join(Classes(a.Val), " ")
+		}
+		r.byte(' ')
+		if a.Namespace != "" {
+			r.str(a.Namespace)
+			r.byte(':')
+		}
+		r.str(a.Key)
+		if r.opts.Minify && !r.opts.XHTML {
+			if a.Val == "" {
+				continue
+			}
+			if !strings.ContainsAny(a.Val, unquotedUnsafe) {
+				r.byte('=')
+				r.str(a.Val)
+				continue
+			}
+		}
+		r.str(`="`)
+		r.escape(a.Val)
+		r.byte('"')
+	}
+	if r.opts.XHTML {
+		r.xmlns(c)
+	}
+}
+
+// unquotedUnsafe are the characters which can not be written in unquoted attribute values.
+const unquotedUnsafe = " \t\n\f\r\"'=<>`&"
+
+// xmlns declares the namespaces of root and foreign elements if they are missing.
+func (r *renderer) xmlns(c *Cursor) {
+	n := c.Node()
+	ns := namespace(n)
+	if p := parentElement(c.path); p >= 0 && namespace(c.path[p]) == ns {
+		return
+	}
+	if ns == "" && n.Data != "html" {
+		return
+	}
+	if n.Attribute("xmlns", "") == nil {
+		r.str(` xmlns="`)
+		r.str(namespaces[ns])
+		r.byte('"')
+	}
+	if ns == "" || n.Attribute("xlink", "xmlns") != nil {
+		return
+	}
+	usesXlink := false
+	n.Walk(WalkFuncs{OnEnter: func(c *Cursor) WalkAction {
+		if n := c.Node(); n != nil {
+			for _, a := range n.Attributes {
+				if a.Namespace == "xlink" {
+					usesXlink = true
+					return Stop
+				}
+			}
+		}
+		return Continue
+	}})
+	if usesXlink {
+		r.str(` xmlns:xlink="`)
+		r.str(namespaces["xlink"])
+		r.byte('"')
+	}
+}
+
+// namespace retrieves the namespace of an element,
+// <svg> and <math> are foreign even without a namespace.
+func namespace(n *Node) string {
+	if n.Namespace == "" && (n.Data == "svg" || n.Data == "math") {
+		return n.Data
+	}
+	return n.Namespace
+}
+
+// removed reports whether n is not written.
+func (r *renderer) removed(n *Node, block bool) bool {
+	switch n.Type {
+	case html.CommentNode:
+		return r.opts.Minify
+	case html.TextNode:
+		return block && (r.opts.Minify || r.opts.Indent != "") && isSpace(n.Data)
+	}
+	return false
+}
+
+// blockChildren reports whether the nodes can be written on separate lines
+// because whitespace between them is not significant.
+// This is only the case if their parent is written on a separate line.
+// Children of foreign elements are block level if they are foreign.
+func (r *renderer) blockChildren(parentBlock, foreign bool, ns Siblings) bool {
+	if r.opts.Indent == "" && !r.opts.Minify || !parentBlock || r.preserve > 0 {
+		return false
+	}
+	for _, n := range ns {
+		if n == nil {
+			continue
+		}
+		switch n.Type {
+		case html.TextNode:
+			if !isSpace(n.Data) {
+				return false
+			}
+		case html.ElementNode:
+			if foreign && n.Namespace != "" {
+				continue
+			}
+			if s, _ := spec(n); s.cat&catPhrasing != 0 && s.cat&catMetadata == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// next retrieves the next sibling of the current node which is written.
+// It starts at the index kept by the cursor, a node may appear more than once in its siblings.
+func (r *renderer) next(c *Cursor) *Node {
+	block := r.frames[len(r.frames)-1].block
+	sibs := c.siblings()
+	for i := c.idx + 1; i < len(sibs); i++ {
+		if n := sibs[i]; n != nil && !r.removed(n, block) {
+			return n
+		}
+	}
+	return nil
+}
+
+// first retrieves the first child of n which is written.
+func (r *renderer) first(n *Node, block bool) *Node {
+	for _, ch := range n.Children {
+		if ch != nil && !r.removed(ch, block) {
+			return ch
+		}
+	}
+	return nil
+}
+
+// omitStartTag reports whether the start tag of n is optional,
+// see html.spec.whatwg.org/multipage/syntax.html#optional-tags
+func (r *renderer) omitStartTag(n *Node, block bool) bool {
+	if n.Namespace != "" || len(n.Attributes) > 0 {
+		return false
+	}
+	first := r.first(n, block)
+	switch n.Data {
+	case "html":
+		return first == nil || first.Type != html.CommentNode
+	case "head":
+		return first == nil || first.Type == html.ElementNode
+	case "body":
+		return first == nil || !spaceOrComment(first) &&
+			!isTag(first, "meta", "noscript", "link", "script", "style", "template")
+	}
+	return false
+}
+
+// omitEndTag reports whether the end tag of the current node is optional,
+// see html.spec.whatwg.org/multipage/syntax.html#optional-tags
+func (r *renderer) omitEndTag(c *Cursor) bool {
+	n := c.Node()
+	p := c.parent()
+	if n.Namespace != "" || p == nil {
+		return false
+	}
+	next := r.next(c)
+	if pn := p.Node(); next == nil && pn.Type == html.ElementNode && pn.Namespace != "" {
+		// end tags of foreign elements do not close HTML elements
+		return false
+	}
+	switch n.Data {
+	case "html", "body":
+		return next == nil || next.Type != html.CommentNode
+	case "head":
+		return next == nil || !spaceOrComment(next)
+	case "colgroup":
+		// <col> and <template> would be added to the <colgroup>
+		return next == nil || !spaceOrComment(next) && !isTag(next, "col", "template")
+	case "caption":
+		return next == nil || isTag(next, "caption", "col", "colgroup", "tbody", "td", "tfoot", "th", "thead", "tr")
+	case "li":
+		return next == nil && closesChildren(p.Node(), n) || isTag(next, "li")
+	case "dt":
+		return isTag(next, "dt", "dd")
+	case "dd":
+		return next == nil && closesChildren(p.Node(), n) || isTag(next, "dd", "dt")
+	case "p":
+		if next == nil {
+			return closesChildren(p.Node(), n)
+		}
+		// <table> is left out, it does not close <p> in quirks mode
+		return isTag(next, "address", "article", "aside", "blockquote", "details", "dialog",
+			"div", "dl", "fieldset", "figcaption", "figure", "footer", "form",
+			"h1", "h2", "h3", "h4", "h5", "h6", "header", "hgroup", "hr", "main",
+			"menu", "nav", "ol", "p", "pre", "search", "section", "ul")
+	case "rt", "rp":
+		// outside of their containers, the end tag of an ancestor may close another element
+		return next == nil && isTag(p.Node(), "ruby", "rtc") ||
+			isTag(next, "rt", "rp") && inScope(c.path, "ruby")
+	case "optgroup":
+		return next == nil && isTag(p.Node(), "select") ||
+			isTag(next, "optgroup", "hr") && inScope(c.path, "select")
+	case "option":
+		// outside of <select>, only an <option> without open descendants is closed
+		return next == nil && isTag(p.Node(), "select", "datalist", "optgroup") ||
+			isTag(next, "option", "optgroup", "hr") && inScope(c.path, "select")
+	case "thead":
+		return isTag(next, "tbody", "tfoot")
+	case "tbody":
+		return next == nil || isTag(next, "tbody", "tfoot")
+	case "tfoot":
+		return next == nil
+	case "tr":
+		return next == nil || isTag(next, "tr")
+	case "td", "th":
+		return next == nil || isTag(next, "td", "th")
+	}
+	return false
+}
+
+// closesChildren reports whether the end of p closes its open <p>, <li>, <dd> or <dt> child n.
+// The spec allows to omit their end tags before the end of most parents,
+// but the end tags of phrasing, formatting and transparent elements like <b>, <span> and <a>
+// are ignored or reorder the tree while such a child is open.
+// An end tag with the name of n would only close n, and the start tag of a sibling
+// of <li>, <dd> or <dt> does not close it while another of them is open.
+func closesChildren(p, n *Node) bool {
+	if isTag(p, "li", "dd", "dt") {
+		return n.Data == "p"
+	}
+	return p.Type != html.ElementNode || p.Data != n.Data && isTag(p, "address", "applet", "article", "aside",
+		"blockquote", "body", "button", "caption", "center", "details", "dialog", "dir",
+		"div", "dl", "fieldset", "figcaption", "figure", "footer", "form",
+		"h1", "h2", "h3", "h4", "h5", "h6", "header", "hgroup", "html", "listing",
+		"main", "marquee", "menu", "nav", "object", "ol", "p", "pre", "search", "section",
+		"summary", "td", "template", "th", "ul")
+}
+
+// inScope reports whether an ancestor of the last node in p is an HTML element with the tag
+// and no element bounding the scope of the parser is between them.
+func inScope(p Path, tag string) bool {
+	for i := len(p) - 2; i >= 0; i-- {
+		switch a := p[i]; {
+		case a == nil || a.Type != html.ElementNode:
+		case isTag(a, tag):
+			return true
+		case a.Namespace != "" || isTag(a, "applet", "caption", "html", "marquee", "object", "table", "td", "template", "th"):
+			return false
+		}
+	}
+	return false
+}
+
+// isTag reports whether n is an HTML element with one of the tags.
+func isTag(n *Node, tags ...string) bool {
+	if !isElement(n) || n.Namespace != "" {
+		return false
+	}
+	for _, t := range tags {
+		if n.Data == t {
+			return true
+		}
+	}
+	return false
+}
+
+func spaceOrComment(n *Node) bool {
+	return n.Type == html.CommentNode ||
+		n.Type == html.TextNode && n.Data != "" && strings.IndexAny(n.Data[:1], asciiSpace) == 0
+}
+
+// preserveSpace reports whether the whitespace in n is significant.
+func preserveSpace(n *Node) bool {
+	return isTag(n, "pre", "textarea", "listing", "plaintext", "script", "style",
+		"xmp", "iframe", "noembed", "noframes", "noscript")
+}
+
+const asciiSpace = " \t\n\f\r"
+
+func isSpace(s string) bool {
+	return strings.Trim(s, asciiSpace) == ""
+}
+
+// collapseSpace replaces runs of whitespace with a single space.
+func collapseSpace(s string) string {
+	if strings.IndexAny(s, asciiSpace) < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for j := 0; j < len(s); j++ {
+		if strings.IndexByte(asciiSpace, s[j]) >= 0 {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(s[j])
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func (r *renderer) doctype(n *Node) {
+	r.str("<!DOCTYPE ")
+	r.escape(n.Data)
+	var p, s string
+	for _, a := range n.Attributes {
+		switch a.Key {
+		case "public":
+			p = a.Val
+		case "system":
+			s = a.Val
+		}
+	}
+	switch {
+	case p != "":
+		r.str(" PUBLIC ")
+		r.doctypeQuoted(p)
+		if s != "" {
+			r.byte(' ')
+			r.doctypeQuoted(s)
+		}
+	case s != "":
+		r.str(" SYSTEM ")
+		r.doctypeQuoted(s)
+	}
+	r.byte('>')
+}
+
+func (r *renderer) doctypeQuoted(s string) {
+	var q byte = '"'
+	if strings.Contains(s, `"`) {
+		if strings.Contains(s, `'`) {
+			if r.err == nil {
+				r.err = errors.New("doctype contains both quote types, cannot be safely rendered")
+			}
+			return
+		}
+		q = '\''
+	}
+	r.byte(q)
+	r.str(strings.ReplaceAll(s, ">", "&gt;"))
+	r.byte(q)
+}
+
+const escapedChars = "&'<>\"\r"
+
+func (r *renderer) escape(s string) {
+	for i := strings.IndexAny(s, escapedChars); i != -1; i = strings.IndexAny(s, escapedChars) {
+		r.str(s[:i])
+		switch s[i] {
+		case '&':
+			r.str("&amp;")
+		case '\'':
+			r.str("&#39;")
+		case '<':
+			r.str("&lt;")
+		case '>':
+			r.str("&gt;")
+		case '"':
+			r.str("&#34;")
+		case '\r':
+			r.str("&#13;")
+		}
+		s = s[i+1:]
+	}
+	r.str(s)
+}
+
+// escapeComment escapes '&' and the '>' which would end a comment:
+// at its start and after '!' or '-'.
+func (r *renderer) escapeComment(s string) {
+	i := 0
+	for j := 0; j < len(s); j++ {
+		switch s[j] {
+		case '&':
+			r.str(s[i:j])
+			r.str("&amp;")
+		case '>':
+			if j > 0 && s[j-1] != '!' && s[j-1] != '-' {
+				continue
+			}
+			r.str(s[i:j])
+			r.str("&gt;")
+		default:
+			continue
+		}
+		i = j + 1
+	}
+	r.str(s[i:])
+}
+
+// firstChild retrieves the first child which is not nil.
+func firstChild(n *Node) *Node {
+	for _, ch := range n.Children {
+		if ch != nil {
+			return ch
+		}
+	}
+	return nil
+}
+
+// parentElement retrieves the index of the parent of the last node in p.
+// Document nodes are skipped, their children are rendered as siblings.
+// It returns -1 if no parent exists.
+func parentElement(p Path) int {
+	for i := len(p) - 2; i >= 0; i-- {
+		if p[i] != nil && p[i].Type != html.DocumentNode {
+			return i
+		}
+	}
+	return -1
+}
+
+// literalChildText reports whether the text children of the last element in p are not escaped.
+func literalChildText(p Path) bool {
+	n := p[len(p)-1]
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+	switch n.Data {
+	case "iframe", "noembed", "noframes", "noscript", "plaintext", "script", "style", "xmp":
+	default:
+		return false
+	}
+	for i := len(p) - 2; i >= 0; i-- {
+		if a := p[i]; a != nil && a.Type == html.ElementNode && a.Namespace != "" {
+			return htmlIntegrationPoint(a)
+		}
+	}
+	return true
+}
+
+// htmlIntegrationPoint reports whether n is a foreign element containing HTML.
+func htmlIntegrationPoint(n *Node) bool {
+	switch n.Namespace {
+	case "math":
+		if n.Data == "annotation-xml" {
+			for _, a := range n.Attributes {
+				if a.Key == "encoding" && (strings.EqualFold(a.Val, "text/html") ||
+					strings.EqualFold(a.Val, "application/xhtml+xml")) {
+					return true
+				}
+			}
+		}
+	case "svg":
+		switch n.Data {
+		case "desc", "foreignObject", "title":
+			return true
+		}
+	}
+	return false
+}
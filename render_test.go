@@ -0,0 +1,121 @@
+package hck_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arnehormann/hck"
+	"golang.org/x/net/html"
+)
+
+// foreign creates an element in namespace ns with children.
+func foreign(ns, name string, children ...*hck.Node) *hck.Node {
+	n := tag(name, children...)
+	n.Namespace = ns
+	return n
+}
+
+func TestRenderLikeHTML(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		n    *hck.Node
+	}{
+		{"escaped text", tag("p", hck.Text("a < b & c"))},
+		{"script", tag("div", tag("script", hck.Text("a < b")), tag("p"))},
+		{"plaintext", tag("body", tag("plaintext", hck.Text("<b>")), tag("p", hck.Text("after")))},
+		{"plaintext in svg", tag("body",
+			foreign("svg", "svg", tag("plaintext", hck.Text("<b>"))),
+			tag("p", hck.Text("after")))},
+		{"plaintext in foreignObject", tag("body",
+			foreign("svg", "svg", foreign("svg", "foreignObject", tag("plaintext", hck.Text("<b>")))),
+			tag("p", hck.Text("after")))},
+		{"void", tag("p", tag("br"), tag("img"))},
+		{"textarea newline", tag("textarea", hck.Text("\nx"))},
+	} {
+		h, err := tc.n.Convert()
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var want strings.Builder
+		if err := html.Render(&want, h); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got := render(t, tc.n); got != want.String() {
+			t.Errorf("%s: got %s, want %s", tc.name, got, want.String())
+		}
+	}
+}
+
+func TestMinify(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		n    *hck.Node
+		html string
+	}{
+		{"list", tag("ul", tag("li", hck.Text("a")), tag("li", hck.Text("b"))), "<ul><li>a<li>b</ul>"},
+		{"paragraphs", tag("div", tag("p", hck.Text("a")), tag("p", hck.Text("b"))), "<div><p>a<p>b</div>"},
+		{"p before table", tag("div", tag("p", hck.Text("a")), tag("table")), "<div><p>a</p><table></table></div>"},
+		{"li in formatting", tag("b", hck.Text(" y "), tag("li", tag("ruby"))), "<b> y <li><ruby></ruby></li></b>"},
+		{"p in a", tag("a", tag("p", hck.Text("x"))), "<a><p>x</p></a>"},
+		{"li in li", tag("ul", tag("li", tag("ol", tag("li", hck.Text("a"))))), "<ul><li><ol><li>a</ol></ul>"},
+		{"p in li", tag("ul", tag("li", tag("p", hck.Text("a")))), "<ul><li><p>a</ul>"},
+		{"dd in li", tag("ul", tag("li", tag("dd", hck.Text("a"))), tag("li")), "<ul><li><dd>a</dd><li></ul>"},
+		{"option", tag("select", tag("option", hck.Text("a")), tag("option", hck.Text("b"))), "<select><option>a<option>b</select>"},
+		{"option outside select", tag("datalist", tag("option", tag("b")), tag("option")), "<datalist><option><b></b></option><option></datalist>"},
+		{"rt outside ruby", tag("span", tag("rt", hck.Text("a"))), "<span><rt>a</rt></span>"},
+		{"p in svg", foreign("svg", "svg", foreign("svg", "foreignObject", tag("p", hck.Text("x")))),
+			"<svg><foreignObject><p>x</p></foreignObject></svg>"},
+		{"void", tag("p", hck.Text("a"), tag("br"), tag("img")), "<p>a<br><img></p>"},
+		{"collapsed space", tag("p", hck.Text(" a \n\t b ")), "<p> a b </p>"},
+		{"adjacent text", tag("p", hck.Text("a "), hck.Text(" b")), "<p>a b</p>"},
+		{"space only text", tag("p", hck.Text("a "), hck.Text(" "), hck.Text(" b")), "<p>a b</p>"},
+		{"comment between text", tag("p", hck.Text("a "), &hck.Node{Type: html.CommentNode, Data: "c"}, hck.Text(" b")), "<p>a b</p>"},
+		{"text around element", tag("p", hck.Text("a "), tag("b", hck.Text(" b")), hck.Text(" c")), "<p>a <b> b</b> c</p>"},
+	} {
+		var b strings.Builder
+		if err := tc.n.RenderWithOptions(&b, hck.RenderOptions{Minify: true}); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if b.String() != tc.html {
+			t.Errorf("%s: got %s, want %s", tc.name, b.String(), tc.html)
+		}
+	}
+}
+
+func TestMinifyReparse(t *testing.T) {
+	for _, src := range []string{
+		`<!DOCTYPE html><title>t</title><p>a<p>b<div><p>c</div>`,
+		`<ul><li>a<li><p>b<li><ol><li>c</ol></ul><dl><dt>a<dd>b<dt>c</dl>`,
+		`<table><caption>c<colgroup><col><thead><tr><th>a<tbody><tr><td>1<td>2<tr><td>3</table>`,
+		`<select><optgroup label=a><option>1<option>2</optgroup><option>3<hr><option>4</select>`,
+		`<ruby>a<rp>(<rt>b<rp>)</ruby>`,
+		`<b> y <li><ruby></ruby></b>`,
+		`<span><li>a</span><a><p>b</a><em><dd>c</em>`,
+		`<p>a<table><tr><td>b</table>`,
+		`<svg><foreignObject><p>a</foreignObject><p>b</svg>`,
+		`<img src=a.png><br class=x><input disabled><p>x<hr id=h>`,
+		`<script>if (a < b) {}</script><textarea>
+x</textarea><pre>
+
+y</pre>`,
+	} {
+		doc, err := hck.Parse(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err := doc.RenderWithOptions(&b, hck.RenderOptions{Minify: true}); err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		re, err := hck.Parse(strings.NewReader(b.String()))
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		if got, want := render(t, re), render(t, doc); got != want {
+			t.Errorf("%s: minified to %s, parsed as\n%s\nwant\n%s", src, b.String(), got, want)
+		}
+	}
+}
@@ -0,0 +1,622 @@
+package hck
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// SelectorError describes a syntax error in a selector.
+type SelectorError struct {
+	Selector string
+	// Offset is the byte offset of the error in Selector
+	Offset int
+	Msg    string
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("selector %q: %s at offset %d", e.Selector, e.Msg, e.Offset)
+}
+
+// Compile parses a group of CSS selectors and retrieves a Matcher for it.
+//
+// Supported are type and universal selectors, #id, .class, attribute selectors
+// with the operators =, ~=, |=, ^=, $= and *=, the combinators " ", ">", "+" and "~"
+// and the structural pseudo-classes :root, :empty, :first-child, :last-child, :only-child,
+// :first-of-type, :last-of-type, :only-of-type, :nth-child(an+b), :nth-last-child(an+b),
+// :nth-of-type(an+b) and :nth-last-of-type(an+b).
+// The logical pseudo-classes :not(), :is() and :where() accept selector lists,
+// :has() accepts relative selectors like "article:has(> h2.title)".
+// Selectors can be grouped with commas.
+func Compile(selector string) (Matcher, error) {
+	p := &selectorParser{s: selector}
+	m, err := p.parseGroup()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.s) {
+		return nil, p.errorf(p.pos, "unexpected %q", p.s[p.pos])
+	}
+	return m, nil
+}
+
+// MustCompile is like Compile but panics if the selector can not be parsed.
+func MustCompile(selector string) Matcher {
+	m, err := Compile(selector)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+type selectorParser struct {
+	s   string
+	pos int
+}
+
+func (p *selectorParser) errorf(offset int, format string, args ...interface{}) error {
+	return &SelectorError{
+		Selector: p.s,
+		Offset:   offset,
+		Msg:      fmt.Sprintf(format, args...),
+	}
+}
+
+func (p *selectorParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+// skipSpace skips whitespace and reports whether any was found.
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\r', '\n', '\f':
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.pos > start
+}
+
+// parseGroup parses comma separated selectors.
+func (p *selectorParser) parseGroup() (Matcher, error) {
+	var ms []Matcher
+	for {
+		p.skipSpace()
+		m, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+		p.skipSpace()
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if len(ms) == 1 {
+		return ms[0], nil
+	}
+	return MatchAny(ms...), nil
+}
+
+// parseSelector parses a selector up to the next comma.
+// Combinators are resolved from right to left, the last compound selector
+// has to match the node and the preceding ones its parents and siblings.
+func (p *selectorParser) parseSelector() (Matcher, error) {
+	m, err := p.parseCompound()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		comb, err := p.parseCombinator()
+		if err != nil || comb == 0 {
+			return m, err
+		}
+		next, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		m = MatchAll(next, combine(comb, m))
+	}
+}
+
+// parseRelativeGroup parses comma separated relative selectors as used in :has().
+func (p *selectorParser) parseRelativeGroup() (Matcher, error) {
+	var ms []Matcher
+	for {
+		p.skipSpace()
+		m, err := p.parseRelative()
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+		p.skipSpace()
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if len(ms) == 1 {
+		return ms[0], nil
+	}
+	return MatchAny(ms...), nil
+}
+
+// parseRelative parses a selector relative to the node it has to match,
+// it starts with an optional combinator.
+// The selector is resolved from left to right, "> a b" matches nodes
+// with a child matching a which has a descendant matching b.
+func (p *selectorParser) parseRelative() (Matcher, error) {
+	comb := byte(' ')
+	switch c := p.peek(); c {
+	case '>', '+', '~':
+		comb = c
+		p.pos++
+		p.skipSpace()
+	}
+	var combs []byte
+	var ms []Matcher
+	for comb != 0 {
+		m, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		combs = append(combs, comb)
+		ms = append(ms, m)
+		if comb, err = p.parseCombinator(); err != nil {
+			return nil, err
+		}
+	}
+	m := ms[len(ms)-1]
+	for i := len(ms) - 2; i >= 0; i-- {
+		m = MatchAll(ms[i], relate(combs[i+1], m))
+	}
+	return relate(combs[0], m), nil
+}
+
+// parseCombinator parses the combinator between two compound selectors.
+// At the end of the selector, it returns 0.
+func (p *selectorParser) parseCombinator() (byte, error) {
+	start := p.pos
+	space := p.skipSpace()
+	switch comb := p.peek(); {
+	case comb == 0 || comb == ',' || comb == ')':
+		p.pos = start
+		return 0, nil
+	case comb == '>' || comb == '+' || comb == '~':
+		p.pos++
+		p.skipSpace()
+		return comb, nil
+	case space:
+		return ' ', nil
+	default:
+		return 0, p.errorf(p.pos, "unexpected %q", comb)
+	}
+}
+
+// combine retrieves a matcher for nodes following a node matching m.
+func combine(comb byte, m Matcher) Matcher {
+	switch comb {
+	case '>':
+		return MatchParent(m)
+	case '+':
+		return MatchPrevSibling(m)
+	case '~':
+		return MatchPrecedingSibling(m)
+	}
+	return MatchAncestor(m)
+}
+
+// relate retrieves a matcher for nodes followed by a node matching m.
+func relate(comb byte, m Matcher) Matcher {
+	switch comb {
+	case '>':
+		return MatchChild(m)
+	case '+':
+		return MatchNextSibling(m)
+	case '~':
+		return MatchFollowingSibling(m)
+	}
+	return MatchHas(m)
+}
+
+// parseCompound parses a sequence of simple selectors.
+func (p *selectorParser) parseCompound() (Matcher, error) {
+	var ms []Matcher
+	start := p.pos
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		ms = append(ms, MatchType(html.ElementNode))
+	case isNameStart(p.s[p.pos:]):
+		tag := p.parseIdent()
+		ms = append(ms, MatchTag(strings.ToLower(tag)))
+	}
+	for {
+		switch c := p.peek(); c {
+		case '#':
+			p.pos++
+			if !isNameChar(p.s[p.pos:]) {
+				return nil, p.errorf(p.pos, "expected id")
+			}
+			ms = append(ms, MatchID(p.parseName()))
+		case '.':
+			p.pos++
+			if !isNameStart(p.s[p.pos:]) {
+				return nil, p.errorf(p.pos, "expected class name")
+			}
+			ms = append(ms, MatchClass(p.parseIdent()))
+		case '[':
+			m, err := p.parseAttribute()
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, m)
+		case ':':
+			m, err := p.parsePseudo()
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, m)
+		default:
+			switch len(ms) {
+			case 0:
+				if p.pos >= len(p.s) {
+					return nil, p.errorf(start, "expected selector")
+				}
+				return nil, p.errorf(start, "unexpected %q", c)
+			case 1:
+				return ms[0], nil
+			}
+			return MatchAll(ms...), nil
+		}
+	}
+}
+
+// parseAttribute parses an attribute selector starting with '['.
+func (p *selectorParser) parseAttribute() (Matcher, error) {
+	p.pos++
+	p.skipSpace()
+	if !isNameStart(p.s[p.pos:]) {
+		return nil, p.errorf(p.pos, "expected attribute name")
+	}
+	key := strings.ToLower(p.parseIdent())
+	p.skipSpace()
+	var op byte
+	switch c := p.peek(); c {
+	case ']':
+		p.pos++
+		return MatchHasAttribute(key, ""), nil
+	case '=':
+		op = c
+		p.pos++
+	case '~', '|', '^', '$', '*':
+		op = c
+		p.pos++
+		if p.peek() != '=' {
+			return nil, p.errorf(p.pos, "expected '='")
+		}
+		p.pos++
+	default:
+		return nil, p.errorf(p.pos, "expected attribute operator or ']'")
+	}
+	p.skipSpace()
+	var val string
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		val = s
+	case isNameStart(p.s[p.pos:]):
+		val = p.parseIdent()
+	default:
+		return nil, p.errorf(p.pos, "expected attribute value")
+	}
+	p.skipSpace()
+	if p.peek() != ']' {
+		return nil, p.errorf(p.pos, "expected ']'")
+	}
+	p.pos++
+	if op == '=' {
+		return MatchAttribute(key, "", val), nil
+	}
+	return matchAttributeOperator(key, "", val, op), nil
+}
+
+// parsePseudo parses a pseudo-class starting with ':'.
+func (p *selectorParser) parsePseudo() (Matcher, error) {
+	start := p.pos
+	p.pos++
+	if p.peek() == ':' {
+		return nil, p.errorf(start, "pseudo-elements are not supported")
+	}
+	if !isNameStart(p.s[p.pos:]) {
+		return nil, p.errorf(p.pos, "expected pseudo-class")
+	}
+	name := strings.ToLower(p.parseIdent())
+	if p.peek() != '(' {
+		switch name {
+		case "root":
+			return MatchRoot(), nil
+		case "empty":
+			return MatchEmpty(), nil
+		case "first-child":
+			return MatchFirstChild(), nil
+		case "last-child":
+			return MatchLastChild(), nil
+		case "only-child":
+			return MatchOnlyChild(), nil
+		case "first-of-type":
+			return MatchFirstOfType(), nil
+		case "last-of-type":
+			return MatchLastOfType(), nil
+		case "only-of-type":
+			return MatchOnlyOfType(), nil
+		}
+		return nil, p.errorf(start, "unsupported pseudo-class %q", name)
+	}
+	p.pos++
+	var nthMatcher func(a, b int) Matcher
+	switch name {
+	case "not", "is", "where", "has":
+		var m Matcher
+		var err error
+		if name == "has" {
+			m, err = p.parseRelativeGroup()
+		} else {
+			m, err = p.parseGroup()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := p.parseClose(); err != nil {
+			return nil, err
+		}
+		if name == "not" {
+			// like all other selectors, :not only matches elements
+			return MatchAll(MatchType(html.ElementNode), MatchNot(m)), nil
+		}
+		return m, nil
+	case "nth-child":
+		nthMatcher = MatchNthChild
+	case "nth-last-child":
+		nthMatcher = MatchNthLastChild
+	case "nth-of-type":
+		nthMatcher = MatchNthOfType
+	case "nth-last-of-type":
+		nthMatcher = MatchNthLastOfType
+	default:
+		return nil, p.errorf(start, "unsupported pseudo-class %q", name)
+	}
+	a, b, err := p.parseNth()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.parseClose(); err != nil {
+		return nil, err
+	}
+	return nthMatcher(a, b), nil
+}
+
+// parseClose parses optional whitespace followed by ')'.
+func (p *selectorParser) parseClose() error {
+	p.skipSpace()
+	if p.peek() != ')' {
+		return p.errorf(p.pos, "expected ')'")
+	}
+	p.pos++
+	return nil
+}
+
+// parseNth parses the argument "an+b", "odd" or "even" of nth pseudo-classes.
+func (p *selectorParser) parseNth() (a, b int, err error) {
+	p.skipSpace()
+	start := p.pos
+	if isNameStart(p.s[p.pos:]) {
+		switch strings.ToLower(p.parseName()) {
+		case "odd":
+			return 2, 1, nil
+		case "even":
+			return 2, 0, nil
+		}
+		p.pos = start
+	}
+	sign := 1
+	switch p.peek() {
+	case '-':
+		sign = -1
+		p.pos++
+	case '+':
+		p.pos++
+	}
+	num, ok := p.parseInt()
+	if c := p.peek(); c != 'n' && c != 'N' {
+		if !ok {
+			return 0, 0, p.errorf(start, "invalid nth expression")
+		}
+		return 0, sign * num, nil
+	}
+	p.pos++
+	if !ok {
+		num = 1
+	}
+	a = sign * num
+	p.skipSpace()
+	switch p.peek() {
+	case '-':
+		sign = -1
+	case '+':
+		sign = 1
+	default:
+		return a, 0, nil
+	}
+	p.pos++
+	p.skipSpace()
+	num, ok = p.parseInt()
+	if !ok {
+		return 0, 0, p.errorf(p.pos, "expected number")
+	}
+	return a, sign * num, nil
+}
+
+// parseInt parses a decimal number without sign.
+func (p *selectorParser) parseInt() (int, bool) {
+	start := p.pos
+	var num int
+	for ; p.pos < len(p.s) && '0' <= p.s[p.pos] && p.s[p.pos] <= '9'; p.pos++ {
+		if num < 1<<24 {
+			num = num*10 + int(p.s[p.pos]-'0')
+		}
+	}
+	return num, p.pos > start
+}
+
+// parseIdent parses a CSS identifier.
+// The caller must ensure it starts with a valid character.
+func (p *selectorParser) parseIdent() string {
+	var b strings.Builder
+	if p.peek() == '-' {
+		b.WriteByte('-')
+		p.pos++
+	}
+	p.appendName(&b)
+	return b.String()
+}
+
+// parseName parses a sequence of name characters.
+func (p *selectorParser) parseName() string {
+	var b strings.Builder
+	p.appendName(&b)
+	return b.String()
+}
+
+func (p *selectorParser) appendName(b *strings.Builder) {
+	for isNameChar(p.s[p.pos:]) {
+		if p.s[p.pos] == '\\' {
+			b.WriteRune(p.parseEscape())
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		b.WriteString(p.s[p.pos : p.pos+size])
+		p.pos += size
+	}
+}
+
+// parseEscape parses an escape sequence starting with '\'.
+func (p *selectorParser) parseEscape() rune {
+	p.pos++
+	var r rune
+	var i int
+	for i = 0; i < 6 && p.pos < len(p.s) && isHex(p.s[p.pos]); i++ {
+		r = r<<4 | rune(unhex(p.s[p.pos]))
+		p.pos++
+	}
+	if i == 0 {
+		r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		p.pos += size
+		return r
+	}
+	// a single whitespace terminates a hex escape
+	switch p.peek() {
+	case ' ', '\t', '\n', '\f':
+		p.pos++
+	case '\r':
+		p.pos++
+		if p.peek() == '\n' {
+			p.pos++
+		}
+	}
+	if r == 0 || r > utf8.MaxRune || 0xd800 <= r && r <= 0xdfff {
+		return utf8.RuneError
+	}
+	return r
+}
+
+// parseString parses a quoted string.
+func (p *selectorParser) parseString() (string, error) {
+	start := p.pos
+	quote := p.s[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		switch c := p.s[p.pos]; c {
+		case quote:
+			p.pos++
+			return b.String(), nil
+		case '\n', '\r', '\f':
+			return "", p.errorf(p.pos, "newline in string")
+		case '\\':
+			if p.pos+1 >= len(p.s) {
+				p.pos++
+				continue
+			}
+			switch p.s[p.pos+1] {
+			case '\n', '\f':
+				p.pos += 2
+				continue
+			case '\r':
+				p.pos += 2
+				if p.peek() == '\n' {
+					p.pos++
+				}
+				continue
+			}
+			b.WriteRune(p.parseEscape())
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf(start, "unterminated string")
+}
+
+func isNameStart(s string) bool {
+	if len(s) > 0 && s[0] == '-' {
+		s = s[1:]
+	}
+	if len(s) == 0 {
+		return false
+	}
+	switch c := s[0]; {
+	case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= 0x80:
+		return true
+	case c == '\\':
+		return len(s) > 1 && s[1] != '\n' && s[1] != '\r' && s[1] != '\f'
+	}
+	return false
+}
+
+func isNameChar(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	switch c := s[0]; {
+	case c == '-' || '0' <= c && c <= '9':
+		return true
+	}
+	return isNameStart(s)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c <= '9':
+		return c - '0'
+	case c <= 'F':
+		return c - 'A' + 10
+	}
+	return c - 'a' + 10
+}
@@ -0,0 +1,155 @@
+package hck_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/arnehormann/hck"
+)
+
+const selectorDoc = `<!DOCTYPE html><html id=html><head id=head></head><body id=body>` +
+	`<nav id=nav><a id=a1 href=/>x</a><span id=s1><a id=a2></a></span></nav>` +
+	`<ul id=ul><li id=l1>1</li><li id=l2 class="x y">2</li><li id=l3>3</li><li id=l4>4</li><li id=l5 lang=en-US>5</li></ul>` +
+	`<p id=p1></p><p id=p2><!--c--></p>` +
+	`<div id=d1 data-x="hello world"><p id=p3>a</p><em id=e1>e</em><p id=p4>b</p></div>` +
+	`<article id=r1><h2 id=h1 class=title>t</h2><p id=p5>x <b id=b1>y</b></p></article>` +
+	`<article id=r2 class=draft><h2 id=h2 class=title>t</h2></article>` +
+	`<article id=r3><div id=d2><h2 id=h3 class=title></h2></div><em id=e2></em></article>` +
+	`</body></html>`
+
+// ids retrieves the ids of the nodes found by m in document order.
+func ids(doc *hck.Node, m hck.Matcher) string {
+	var s []string
+	for _, n := range doc.Find(m).All() {
+		s = append(s, n.Attributes.ID())
+	}
+	return strings.Join(s, " ")
+}
+
+func TestCompile(t *testing.T) {
+	doc, err := hck.Parse(strings.NewReader(selectorDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		selector string
+		ids      string
+	}{
+		// simple selectors
+		{"ul", "ul"},
+		{"UL", "ul"},
+		{"#l3", "l3"},
+		{".y", "l2"},
+		{"li.x.y#l2", "l2"},
+		{".\\79", "l2"},
+		{"[href]", "a1"},
+		{"[href=\"/\"]", "a1"},
+		{"[data-x~=world]", "d1"},
+		{"[lang|=en]", "l5"},
+		{"[data-x^=hel]", "d1"},
+		{"[data-x$='rld']", "d1"},
+		{"[data-x*=\"o w\"]", "d1"},
+		{"[data-x^='']", ""},
+		{"nav, em", "nav e1 e2"},
+		// combinators
+		{"nav > a", "a1"},
+		{"nav a", "a1 a2"},
+		{"li + li", "l2 l3 l4 l5"},
+		{"l1 ~ li", ""},
+		{"#l3 ~ li", "l4 l5"},
+		{"p + em", "e1"},
+		{"body > p", "p1 p2"},
+		// structural pseudo-classes
+		{":root", "html"},
+		{"li:first-child", "l1"},
+		{"li:last-child", "l5"},
+		{"a:only-child", "a2"},
+		{"li:nth-child(odd)", "l1 l3 l5"},
+		{"li:nth-child(2n)", "l2 l4"},
+		{"li:nth-child(-n+2)", "l1 l2"},
+		{"li:nth-last-child(2)", "l4"},
+		{"div p:nth-of-type(2)", "p4"},
+		{"div > p:last-of-type", "p4"},
+		{"em:only-of-type", "e1 e2"},
+		{"p:empty", "p1 p2"},
+		// logical pseudo-classes
+		{"article:has(> h2.title):not(.draft)", "r1"},
+		{"article:has(> div > h2)", "r3"},
+		{"h2:has(+ p)", "h1"},
+		{"h2:has(~ p b)", "h1"},
+		{"article :is(h2, em)", "h1 h2 h3 e2"},
+		{"article :where(p b, em)", "b1 e2"},
+		{"article:not(:has(em))", "r1 r2"},
+		{"article > :not(h2, p)", "d2 e2"},
+	} {
+		m, err := hck.Compile(tc.selector)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tc.selector, err)
+			continue
+		}
+		if got := ids(doc, m); got != tc.ids {
+			t.Errorf("Compile(%q) found %q, want %q", tc.selector, got, tc.ids)
+		}
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, tc := range []struct {
+		selector string
+		offset   int
+	}{
+		{"", 0},
+		{"div,", 4},
+		{"a >", 3},
+		{"a > > b", 4},
+		{"[a", 2},
+		{"[a=]", 3},
+		{"[a='x", 3},
+		{"#", 1},
+		{"a!", 1},
+		{"a::before", 1},
+		{"a:hover", 1},
+		{"a:nth-child(x)", 12},
+		{":not(", 5},
+		{":has()", 5},
+		{":is(a,)", 6},
+	} {
+		_, err := hck.Compile(tc.selector)
+		var se *hck.SelectorError
+		if !errors.As(err, &se) {
+			t.Errorf("Compile(%q): got error %v, want a SelectorError", tc.selector, err)
+			continue
+		}
+		if se.Offset != tc.offset {
+			t.Errorf("Compile(%q): %v, want offset %d", tc.selector, err, tc.offset)
+		}
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	doc, err := hck.Parse(strings.NewReader(selectorDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		m    hck.Matcher
+		ids  string
+	}{
+		{"tag", hck.MatchTag("h2"), "h1 h2 h3"},
+		{"class", hck.MatchClass("title"), "h1 h2 h3"},
+		{"not", hck.MatchAll(hck.MatchTag("article"), hck.MatchNot(hck.MatchClass("draft"))), "r1 r3"},
+		{"child", hck.MatchAll(hck.MatchTag("article"), hck.MatchChild(hck.MatchTag("h2"))), "r1 r2"},
+		{"any", hck.MatchAny(hck.MatchID("l1"), hck.MatchID("e2")), "l1 e2"},
+		{"word", hck.MatchAttributeWord("class", "", "x"), "l2"},
+	} {
+		if got := ids(doc, tc.m); got != tc.ids {
+			t.Errorf("%s found %q, want %q", tc.name, got, tc.ids)
+		}
+	}
+	// nested finders search the subtree of the previous match
+	if got := len(doc.Find(hck.MustCompile("nav"), hck.MustCompile("span > a")).All()); got != 1 {
+		t.Errorf("nested finders found %d nodes, want 1", got)
+	}
+}
@@ -0,0 +1,265 @@
+package hck
+
+import "golang.org/x/net/html"
+
+// matchRoot matches a node without context as the root of its tree.
+func matchRoot(m CursorMatcher, n *Node) bool {
+	if n == nil {
+		return false
+	}
+	return m.MatchCursor(n.Cursor())
+}
+
+func isElement(n *Node) bool {
+	return n != nil && n.Type == html.ElementNode
+}
+
+// MatchParent matches nodes with a parent matching m (CSS "parent > node").
+func MatchParent(m Matcher) Matcher {
+	return matchParent{m}
+}
+
+type matchParent struct {
+	m Matcher
+}
+
+func (m matchParent) Match(n *Node) bool {
+	return matchRoot(m, n)
+}
+
+func (m matchParent) MatchCursor(c *Cursor) bool {
+	p := c.parent()
+	return p != nil && Matches(m.m, p)
+}
+
+// MatchAncestor matches nodes with an ancestor matching m (CSS "ancestor node").
+func MatchAncestor(m Matcher) Matcher {
+	return matchAncestor{m}
+}
+
+type matchAncestor struct {
+	m Matcher
+}
+
+func (m matchAncestor) Match(n *Node) bool {
+	return matchRoot(m, n)
+}
+
+func (m matchAncestor) MatchCursor(c *Cursor) bool {
+	for p := c.parent(); p != nil; p = p.parent() {
+		if Matches(m.m, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchHas matches nodes with a descendant matching m (CSS ":has(descendant)").
+func MatchHas(m Matcher) Matcher {
+	return matchHas{m}
+}
+
+type matchHas struct {
+	m Matcher
+}
+
+func (m matchHas) Match(n *Node) bool {
+	return matchRoot(m, n)
+}
+
+func (m matchHas) MatchCursor(c *Cursor) bool {
+	return c.sub().Seek(m.m)
+}
+
+// MatchPrevSibling matches nodes where the previous element sibling matches m (CSS "prev + node").
+func MatchPrevSibling(m Matcher) Matcher {
+	return &matchSibling{m: m, step: -1}
+}
+
+// MatchPrecedingSibling matches nodes where any previous element sibling matches m (CSS "prev ~ node").
+func MatchPrecedingSibling(m Matcher) Matcher {
+	return &matchSibling{m: m, step: -1, any: true}
+}
+
+// MatchNextSibling matches nodes where the next element sibling matches m (CSS ":has(+ next)").
+func MatchNextSibling(m Matcher) Matcher {
+	return &matchSibling{m: m, step: 1}
+}
+
+// MatchFollowingSibling matches nodes where any following element sibling matches m (CSS ":has(~ next)").
+func MatchFollowingSibling(m Matcher) Matcher {
+	return &matchSibling{m: m, step: 1, any: true}
+}
+
+type matchSibling struct {
+	m    Matcher
+	step int
+	any  bool
+}
+
+func (m *matchSibling) Match(n *Node) bool {
+	return matchRoot(m, n)
+}
+
+func (m *matchSibling) MatchCursor(c *Cursor) bool {
+	sibs := c.siblings()
+	if len(sibs) == 0 {
+		return false
+	}
+	var s *Cursor
+	for i := c.idx + m.step; 0 <= i && i < len(sibs); i += m.step {
+		n := sibs[i]
+		if !isElement(n) {
+			continue
+		}
+		if s == nil {
+			s = c.Cursor()
+		}
+		s.path[len(s.path)-1] = n
+		s.idx = i
+		if Matches(m.m, s) {
+			return true
+		}
+		if !m.any {
+			return false
+		}
+	}
+	return false
+}
+
+// MatchRoot matches the root element (CSS ":root").
+// Its parent is a document node or it has no parent.
+func MatchRoot() Matcher {
+	return matchStructure(func(c *Cursor) bool {
+		p := c.parent()
+		return isElement(c.Node()) &&
+			(p == nil || p.Node().Type == html.DocumentNode)
+	})
+}
+
+// MatchEmpty matches elements without children other than comments (CSS ":empty").
+func MatchEmpty() Matcher {
+	return matchStructure(func(c *Cursor) bool {
+		n := c.Node()
+		if !isElement(n) {
+			return false
+		}
+		for _, ch := range n.Children {
+			switch {
+			case ch == nil, ch.Type == html.CommentNode:
+			case ch.Type == html.TextNode && ch.Data == "":
+			default:
+				return false
+			}
+		}
+		return true
+	})
+}
+
+type matchStructure func(c *Cursor) bool
+
+func (m matchStructure) Match(n *Node) bool {
+	return matchRoot(m, n)
+}
+
+func (m matchStructure) MatchCursor(c *Cursor) bool {
+	return m(c)
+}
+
+// MatchFirstChild matches elements without previous element siblings (CSS ":first-child").
+func MatchFirstChild() Matcher {
+	return MatchNthChild(0, 1)
+}
+
+// MatchLastChild matches elements without following element siblings (CSS ":last-child").
+func MatchLastChild() Matcher {
+	return MatchNthLastChild(0, 1)
+}
+
+// MatchOnlyChild matches elements without element siblings (CSS ":only-child").
+func MatchOnlyChild() Matcher {
+	return MatchAll(MatchFirstChild(), MatchLastChild())
+}
+
+// MatchFirstOfType matches elements without previous element siblings with the same tag (CSS ":first-of-type").
+func MatchFirstOfType() Matcher {
+	return MatchNthOfType(0, 1)
+}
+
+// MatchLastOfType matches elements without following element siblings with the same tag (CSS ":last-of-type").
+func MatchLastOfType() Matcher {
+	return MatchNthLastOfType(0, 1)
+}
+
+// MatchOnlyOfType matches elements without element siblings with the same tag (CSS ":only-of-type").
+func MatchOnlyOfType() Matcher {
+	return MatchAll(MatchFirstOfType(), MatchLastOfType())
+}
+
+// MatchNthChild matches elements at the positions a*n+b for any n >= 0
+// among their element siblings (CSS ":nth-child(an+b)").
+// Positions start at 1.
+func MatchNthChild(a, b int) Matcher {
+	return &matchNth{a: a, b: b}
+}
+
+// MatchNthLastChild is like MatchNthChild but counts from the last sibling (CSS ":nth-last-child(an+b)").
+func MatchNthLastChild(a, b int) Matcher {
+	return &matchNth{a: a, b: b, last: true}
+}
+
+// MatchNthOfType is like MatchNthChild but only counts siblings with the same tag (CSS ":nth-of-type(an+b)").
+func MatchNthOfType(a, b int) Matcher {
+	return &matchNth{a: a, b: b, ofType: true}
+}
+
+// MatchNthLastOfType is like MatchNthOfType but counts from the last sibling (CSS ":nth-last-of-type(an+b)").
+func MatchNthLastOfType(a, b int) Matcher {
+	return &matchNth{a: a, b: b, last: true, ofType: true}
+}
+
+type matchNth struct {
+	a, b   int
+	last   bool
+	ofType bool
+}
+
+func (m *matchNth) Match(n *Node) bool {
+	return matchRoot(m, n)
+}
+
+func (m *matchNth) MatchCursor(c *Cursor) bool {
+	n := c.Node()
+	if !isElement(n) {
+		return false
+	}
+	sibs, idx := c.siblings(), c.idx
+	if len(sibs) == 0 {
+		sibs, idx = Siblings{n}, 0
+	}
+	step := -1
+	if m.last {
+		step = 1
+	}
+	pos := 1
+	for i := idx + step; 0 <= i && i < len(sibs); i += step {
+		s := sibs[i]
+		if !isElement(s) {
+			continue
+		}
+		if m.ofType && (s.Data != n.Data || s.Namespace != n.Namespace) {
+			continue
+		}
+		pos++
+	}
+	return nth(m.a, m.b, pos)
+}
+
+// nth reports whether pos is a*n+b for any n >= 0.
+func nth(a, b, pos int) bool {
+	if a == 0 {
+		return pos == b
+	}
+	d := pos - b
+	return d%a == 0 && d/a >= 0
+}
@@ -0,0 +1,246 @@
+package hck
+
+// Tree is an immutable tree of nodes.
+// Edits through a TreeCursor retrieve a new tree which shares all unchanged subtrees
+// and copies the changed nodes and their ancestors.
+// Trees are cheap snapshots, they can be read concurrently and kept for undo.
+//
+// The nodes of a tree must not be modified.
+type Tree struct {
+	root *Node
+}
+
+// NewTree retrieves a tree with the root n.
+// n and its descendants belong to the tree and must not be modified afterwards,
+// use DeepClone to keep a modifiable copy.
+func NewTree(n *Node) *Tree {
+	return &Tree{root: n}
+}
+
+// Root retrieves the root node, it must not be modified.
+func (t *Tree) Root() *Node {
+	return t.root
+}
+
+// Cursor retrieves a cursor on the root node.
+func (t *Tree) Cursor() *TreeCursor {
+	if t.root == nil {
+		return nil
+	}
+	return &TreeCursor{c: t.root.Cursor()}
+}
+
+// TreeCursor points to a node of a Tree.
+// It navigates like a Cursor, but edits do not modify the tree.
+// They retrieve a cursor on a new tree instead, the cursor itself does not change.
+// If an edit is not possible, nil is returned.
+//
+// Inserted nodes belong to the new tree and must not be modified afterwards.
+type TreeCursor struct {
+	c *Cursor
+}
+
+// Cursor creates a new cursor pointing to the same node.
+func (t *TreeCursor) Cursor() *TreeCursor {
+	return &TreeCursor{c: t.c.Cursor()}
+}
+
+// Tree retrieves the tree of the cursor.
+func (t *TreeCursor) Tree() *Tree {
+	return &Tree{root: t.c.path[0]}
+}
+
+// Node retrieves the current node, it must not be modified.
+func (t *TreeCursor) Node() *Node {
+	return t.c.Node()
+}
+
+// Path retrieves the path to the current node.
+func (t *TreeCursor) Path() Path {
+	return t.c.Path()
+}
+
+// Index retrieves the index of the current node in its parent's children.
+// If no parent exists, it returns -1.
+func (t *TreeCursor) Index() int {
+	return t.c.Index()
+}
+
+// Depth retrieves the number of ancestor nodes.
+func (t *TreeCursor) Depth() int {
+	return t.c.Depth()
+}
+
+// Seek moves to the depth-first next node matching m.
+// If no match is found, it returns false.
+func (t *TreeCursor) Seek(m Matcher) bool {
+	return t.c.Seek(m)
+}
+
+// SeekPrev moves to the depth-first previous node matching m.
+// If no match is found, it returns false.
+func (t *TreeCursor) SeekPrev(m Matcher) bool {
+	return t.c.SeekPrev(m)
+}
+
+// PrevSibling moves to and retrieves the previous sibling node.
+// If no previous sibling exists, the cursor does not move and nil is returned.
+func (t *TreeCursor) PrevSibling() *Node {
+	return t.c.PrevSibling()
+}
+
+// NextSibling moves to and retrieves the next sibling node.
+// If no next sibling exists, the cursor does not move and nil is returned.
+func (t *TreeCursor) NextSibling() *Node {
+	return t.c.NextSibling()
+}
+
+// Parent moves to and retrieves the parent node.
+// If no parent exists, the cursor does not move and nil is returned.
+func (t *TreeCursor) Parent() *Node {
+	return t.c.Parent()
+}
+
+// FirstChild moves to and retrieves the first child node.
+// If no children exist, the cursor does not move and nil is returned.
+func (t *TreeCursor) FirstChild() *Node {
+	return t.c.FirstChild()
+}
+
+// LastChild moves to and retrieves the last child node.
+// If no children exist, the cursor does not move and nil is returned.
+func (t *TreeCursor) LastChild() *Node {
+	return t.c.LastChild()
+}
+
+// Prev moves to and retrieves the depth-first previous node.
+// If no previous node exists, the cursor does not move and nil is returned.
+func (t *TreeCursor) Prev() *Node {
+	return t.c.Prev()
+}
+
+// Next moves to and retrieves the depth-first next node.
+// If no next node exists, the cursor does not move and nil is returned.
+func (t *TreeCursor) Next() *Node {
+	return t.c.Next()
+}
+
+// edit retrieves the path to a copy of the node at depth d changed by f
+// in a new tree. The ancestors are copied, too.
+func (t *TreeCursor) edit(d int, f func(n *Node)) Path {
+	p := make(Path, d+1)
+	p[d] = t.c.path[d].Clone()
+	f(p[d])
+	for i := d - 1; i >= 0; i-- {
+		old := t.c.path[i]
+		n := *old
+		n.Children = append(Siblings(nil), old.Children...)
+		n.Children[t.c.index(i+1)] = p[i+1]
+		p[i] = &n
+	}
+	return p
+}
+
+// at retrieves a cursor on the last node of p with the index idx.
+// p is a copy of the path of t up to its last node.
+func (t *TreeCursor) at(p Path, idx int) *TreeCursor {
+	var up []int
+	if len(p) > 2 {
+		up = append(up, t.c.up[:len(p)-2]...)
+	}
+	return &TreeCursor{c: &Cursor{path: p, idx: idx, up: up}}
+}
+
+// child retrieves a cursor on the child i of the last node of p.
+func (t *TreeCursor) child(p Path, i int) *TreeCursor {
+	return t.at(append(p, p[len(p)-1].Children[i]), i)
+}
+
+// Update retrieves a cursor on a copy of the current node changed by f.
+// f may change the fields of the copy and its own Children and Attributes slices,
+// but not the children.
+func (t *TreeCursor) Update(f func(n *Node)) *TreeCursor {
+	p := t.edit(t.c.Depth(), f)
+	return t.at(p, t.c.idx)
+}
+
+// SetAttr retrieves a cursor on a copy of the current node with the attribute set to value.
+func (t *TreeCursor) SetAttr(key, value string) *TreeCursor {
+	return t.Update(func(n *Node) {
+		n.SetAttr(key, value)
+	})
+}
+
+// AppendChild retrieves a cursor on a copy of the current node with nodes added after its last child.
+func (t *TreeCursor) AppendChild(ns ...*Node) *TreeCursor {
+	return t.Update(func(n *Node) {
+		n.Children = n.Children.Splice(len(n.Children), 0, ns...)
+	})
+}
+
+// PrependChild retrieves a cursor on a copy of the current node with nodes added before its first child.
+func (t *TreeCursor) PrependChild(ns ...*Node) *TreeCursor {
+	return t.Update(func(n *Node) {
+		n.Children = n.Children.Splice(0, 0, ns...)
+	})
+}
+
+// splice retrieves a cursor on the child i of a copy of the parent
+// with del children starting at at replaced by ns.
+// If the current node has no parent, nil is returned.
+func (t *TreeCursor) splice(at, del, i int, ns ...*Node) *TreeCursor {
+	d := t.c.Depth()
+	if d == 0 {
+		return nil
+	}
+	p := t.edit(d-1, func(n *Node) {
+		n.Children = n.Children.Splice(at, del, ns...)
+	})
+	return t.child(p, i)
+}
+
+// InsertBefore retrieves a cursor on the current node with nodes inserted as previous siblings.
+// If the current node has no parent, nil is returned.
+func (t *TreeCursor) InsertBefore(ns ...*Node) *TreeCursor {
+	return t.splice(t.c.idx, 0, t.c.idx+len(ns), ns...)
+}
+
+// InsertAfter retrieves a cursor on the current node with nodes inserted as next siblings.
+// If the current node has no parent, nil is returned.
+func (t *TreeCursor) InsertAfter(ns ...*Node) *TreeCursor {
+	return t.splice(t.c.idx+1, 0, t.c.idx, ns...)
+}
+
+// Replace retrieves a cursor on n replacing the current node.
+// If the current node is the root, n is the root of the new tree.
+func (t *TreeCursor) Replace(n *Node) *TreeCursor {
+	if t.c.Depth() == 0 {
+		return &TreeCursor{c: n.Cursor()}
+	}
+	return t.splice(t.c.idx, 1, t.c.idx, n)
+}
+
+// Remove retrieves a cursor on the depth-first previous node
+// in a new tree without the current node, like Cursor.Remove.
+// If the current node has no parent, nil is returned.
+func (t *TreeCursor) Remove() *TreeCursor {
+	d := t.c.Depth()
+	if d == 0 {
+		return nil
+	}
+	i := t.c.idx
+	p := t.edit(d-1, func(n *Node) {
+		n.Children = n.Children.Splice(i, 1)
+	})
+	if i == 0 {
+		idx := -1
+		if d > 1 {
+			idx = t.c.index(d - 1)
+		}
+		return t.at(p, idx)
+	}
+	c := t.child(p, i-1)
+	for c.LastChild() != nil {
+	}
+	return c
+}
@@ -0,0 +1,415 @@
+package hck
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Violation is a node breaking the content model of its parent.
+type Violation struct {
+	// Path of the offending node
+	Path Path
+	Msg  string
+}
+
+func (v Violation) String() string {
+	return v.Path.String() + ": " + v.Msg
+}
+
+// ValidationError is reported for trees with content model violations.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 0 {
+		return "invalid content"
+	}
+	msg := "invalid content at " + e.Violations[0].String()
+	if more := len(e.Violations) - 1; more > 0 {
+		msg += " (and " + strconv.Itoa(more) + " more)"
+	}
+	return msg
+}
+
+// content categories of HTML elements
+const (
+	catMetadata = 1 << iota
+	catFlow
+	catPhrasing
+	catInteractive
+)
+
+// content model kinds
+const (
+	// any content, the model is not checked
+	modelAny = iota
+	// flow content and the listed elements
+	modelFlow
+	// phrasing content and the listed elements
+	modelPhrasing
+	// only the listed elements, no text
+	modelElements
+	// only text
+	modelText
+	// no content at all
+	modelEmpty
+	// the content model of the parent
+	modelTransparent
+)
+
+type contentModel struct {
+	kind  int
+	elems map[string]bool
+}
+
+// allows reports whether an element with the categories cat and the tag can be a child.
+func (m contentModel) allows(cat int, tag string) bool {
+	switch m.kind {
+	case modelAny:
+		return true
+	case modelFlow:
+		return cat&catFlow != 0 || m.elems[tag]
+	case modelPhrasing:
+		return cat&catPhrasing != 0 || m.elems[tag]
+	case modelElements:
+		return m.elems[tag]
+	}
+	return false
+}
+
+// allowsText reports whether non-whitespace text can be a child.
+func (m contentModel) allowsText() bool {
+	switch m.kind {
+	case modelAny, modelFlow, modelPhrasing, modelText:
+		return true
+	}
+	return false
+}
+
+type elementSpec struct {
+	cat   int
+	model contentModel
+}
+
+func model(kind int, elems ...string) contentModel {
+	m := contentModel{kind: kind}
+	if len(elems) > 0 {
+		m.elems = make(map[string]bool, len(elems))
+		for _, e := range elems {
+			m.elems[e] = true
+		}
+	}
+	return m
+}
+
+var (
+	flowModel     = model(modelFlow)
+	phrasingModel = model(modelPhrasing)
+	textModel     = model(modelText)
+	emptyModel    = model(modelEmpty)
+	transparent   = model(modelTransparent)
+	anyModel      = model(modelAny)
+	headingModel  = model(modelPhrasing, "h1", "h2", "h3", "h4", "h5", "h6")
+	rowsModel     = model(modelElements, "tr", "script", "template")
+)
+
+const (
+	flow     = catFlow
+	phrasing = catFlow | catPhrasing
+)
+
+// elementSpecs are the content categories and models of HTML elements, see
+// html.spec.whatwg.org/multipage/indices.html#elements-3
+//
+// Models are stricter than the specification where the HTML parser
+// would move nodes, e.g. <tr> needs a <tbody> in a <table>.
+var elementSpecs = map[string]elementSpec{
+	"a":          {phrasing | catInteractive, transparent},
+	"abbr":       {phrasing, phrasingModel},
+	"address":    {flow, flowModel},
+	"area":       {phrasing, emptyModel},
+	"article":    {flow, flowModel},
+	"aside":      {flow, flowModel},
+	"audio":      {phrasing, model(modelTransparent, "source", "track")},
+	"b":          {phrasing, phrasingModel},
+	"base":       {catMetadata, emptyModel},
+	"bdi":        {phrasing, phrasingModel},
+	"bdo":        {phrasing, phrasingModel},
+	"blockquote": {flow, flowModel},
+	"body":       {0, flowModel},
+	"br":         {phrasing, emptyModel},
+	"button":     {phrasing | catInteractive, phrasingModel},
+	"canvas":     {phrasing, transparent},
+	"caption":    {0, flowModel},
+	"cite":       {phrasing, phrasingModel},
+	"code":       {phrasing, phrasingModel},
+	"col":        {0, emptyModel},
+	"colgroup":   {0, model(modelElements, "col", "template")},
+	"data":       {phrasing, phrasingModel},
+	"datalist":   {phrasing, model(modelPhrasing, "option")},
+	"dd":         {0, flowModel},
+	"del":        {phrasing, transparent},
+	"details":    {flow | catInteractive, model(modelFlow, "summary")},
+	"dfn":        {phrasing, phrasingModel},
+	"dialog":     {flow, flowModel},
+	"div":        {flow, model(modelFlow, "dt", "dd")},
+	"dl":         {flow, model(modelElements, "dt", "dd", "div", "script", "template")},
+	"dt":         {0, flowModel},
+	"em":         {phrasing, phrasingModel},
+	"embed":      {phrasing | catInteractive, emptyModel},
+	"fieldset":   {flow, model(modelFlow, "legend")},
+	"figcaption": {0, flowModel},
+	"figure":     {flow, model(modelFlow, "figcaption")},
+	"footer":     {flow, flowModel},
+	"form":       {flow, flowModel},
+	"h1":         {flow, phrasingModel},
+	"h2":         {flow, phrasingModel},
+	"h3":         {flow, phrasingModel},
+	"h4":         {flow, phrasingModel},
+	"h5":         {flow, phrasingModel},
+	"h6":         {flow, phrasingModel},
+	"head":       {0, model(modelElements, "base", "link", "meta", "noscript", "script", "style", "template", "title")},
+	"header":     {flow, flowModel},
+	"hgroup":     {flow, model(modelElements, "h1", "h2", "h3", "h4", "h5", "h6", "p", "script", "template")},
+	"hr":         {flow, emptyModel},
+	"html":       {0, model(modelElements, "head", "body")},
+	"i":          {phrasing, phrasingModel},
+	"iframe":     {phrasing | catInteractive, emptyModel},
+	"img":        {phrasing, emptyModel},
+	"input":      {phrasing | catInteractive, emptyModel},
+	"ins":        {phrasing, transparent},
+	"kbd":        {phrasing, phrasingModel},
+	"label":      {phrasing | catInteractive, phrasingModel},
+	"legend":     {0, headingModel},
+	"li":         {0, flowModel},
+	"link":       {catMetadata | phrasing, emptyModel},
+	"main":       {flow, flowModel},
+	"map":        {phrasing, transparent},
+	"mark":       {phrasing, phrasingModel},
+	"menu":       {flow, model(modelElements, "li", "script", "template")},
+	"meta":       {catMetadata | phrasing, emptyModel},
+	"meter":      {phrasing, phrasingModel},
+	"nav":        {flow, flowModel},
+	"noscript":   {catMetadata | phrasing, transparent},
+	"object":     {phrasing, transparent},
+	"ol":         {flow, model(modelElements, "li", "script", "template")},
+	"optgroup":   {0, model(modelElements, "option", "script", "template")},
+	"option":     {0, textModel},
+	"output":     {phrasing, phrasingModel},
+	"p":          {flow, phrasingModel},
+	"picture":    {phrasing, model(modelElements, "source", "img", "script", "template")},
+	"pre":        {flow, phrasingModel},
+	"progress":   {phrasing, phrasingModel},
+	"q":          {phrasing, phrasingModel},
+	"rp":         {0, textModel},
+	"rt":         {0, phrasingModel},
+	"ruby":       {phrasing, model(modelPhrasing, "rp", "rt")},
+	"s":          {phrasing, phrasingModel},
+	"samp":       {phrasing, phrasingModel},
+	"script":     {catMetadata | phrasing, textModel},
+	"search":     {flow, flowModel},
+	"section":    {flow, flowModel},
+	"select":     {phrasing | catInteractive, model(modelElements, "option", "optgroup", "hr", "script", "template")},
+	"slot":       {phrasing, transparent},
+	"small":      {phrasing, phrasingModel},
+	"source":     {0, emptyModel},
+	"span":       {phrasing, phrasingModel},
+	"strong":     {phrasing, phrasingModel},
+	"style":      {catMetadata, textModel},
+	"sub":        {phrasing, phrasingModel},
+	"summary":    {0, headingModel},
+	"sup":        {phrasing, phrasingModel},
+	"table":      {flow, model(modelElements, "caption", "colgroup", "thead", "tbody", "tfoot", "script", "template")},
+	"tbody":      {0, rowsModel},
+	"td":         {0, flowModel},
+	"template":   {catMetadata | phrasing, anyModel},
+	"textarea":   {phrasing | catInteractive, textModel},
+	"tfoot":      {0, rowsModel},
+	"th":         {0, flowModel},
+	"thead":      {0, rowsModel},
+	"time":       {phrasing, phrasingModel},
+	"title":      {catMetadata, textModel},
+	"tr":         {0, model(modelElements, "td", "th", "script", "template")},
+	"track":      {0, emptyModel},
+	"u":          {phrasing, phrasingModel},
+	"ul":         {flow, model(modelElements, "li", "script", "template")},
+	"var":        {phrasing, phrasingModel},
+	"video":      {phrasing, model(modelTransparent, "source", "track")},
+	"wbr":        {phrasing, emptyModel},
+}
+
+// notNested are elements which can not contain descendants of the listed categories or tags.
+var notNested = map[string]struct {
+	cat  int
+	tags []string
+}{
+	"a":      {cat: catInteractive},
+	"button": {cat: catInteractive},
+	"form":   {tags: []string{"form"}},
+	"label":  {tags: []string{"label"}},
+}
+
+// foreign reports whether n is an SVG or MathML element.
+func foreign(n *Node) bool {
+	return n.Namespace != "" || n.Data == "svg" || n.Data == "math"
+}
+
+// spec retrieves the element spec of n.
+// Foreign and custom elements are phrasing content with any content.
+func spec(n *Node) (s elementSpec, known bool) {
+	if foreign(n) || strings.Contains(n.Data, "-") {
+		return elementSpec{phrasing, anyModel}, true
+	}
+	s, known = elementSpecs[n.Data]
+	if !known {
+		s = elementSpec{phrasing, anyModel}
+	}
+	return s, known
+}
+
+// Validate checks the tree below n against the content models of HTML
+// and retrieves all violations.
+// The tree must not contain cycles.
+//
+// Unknown elements and children which would be moved by the HTML parser
+// are reported. Foreign and custom elements and their content are not checked.
+func (n *Node) Validate() []Violation {
+	if n == nil {
+		return nil
+	}
+	return n.Cursor().Validate()
+}
+
+// Validate checks the current node and its descendants like Node.Validate.
+func (c *Cursor) Validate() []Violation {
+	v := &validator{models: []contentModel{anyModel}}
+	c.Walk(v)
+	return v.violations
+}
+
+type validator struct {
+	// effective content models of the ancestors
+	models     []contentModel
+	violations []Violation
+}
+
+func (v *validator) report(c *Cursor, msg string) {
+	v.violations = append(v.violations, Violation{
+		Path: c.Path(),
+		Msg:  msg,
+	})
+}
+
+func (v *validator) Enter(c *Cursor) WalkAction {
+	n := c.Node()
+	parent := v.models[len(v.models)-1]
+	if n == nil {
+		v.models = append(v.models, anyModel)
+		return SkipChildren
+	}
+	switch n.Type {
+	case html.TextNode:
+		if !parent.allowsText() && strings.TrimSpace(n.Data) != "" {
+			v.report(c, "text is not allowed in "+parentName(c))
+		}
+	case html.ElementNode:
+		s, known := spec(n)
+		if !known {
+			v.report(c, "unknown element <"+n.Data+">")
+		}
+		if c.Depth() > c.top && !parent.allows(s.cat, n.Data) {
+			v.report(c, "<"+n.Data+"> is not allowed in "+parentName(c))
+		}
+		v.checkNesting(c, s.cat)
+		m := s.model
+		switch m.kind {
+		case modelEmpty:
+			if len(n.Children) > 0 {
+				v.report(c, "<"+n.Data+"> can not have children")
+			}
+		case modelText:
+			for _, ch := range n.Children {
+				if ch != nil && ch.Type == html.ElementNode {
+					v.report(c, "<"+n.Data+"> can only contain text")
+					break
+				}
+			}
+		case modelTransparent:
+			m = parent.with(m.elems)
+		}
+		v.models = append(v.models, m)
+		if foreign(n) {
+			// foreign content is not checked
+			return SkipChildren
+		}
+		return Continue
+	case html.DoctypeNode:
+		if p := c.parent(); p != nil && p.Node().Type != html.DocumentNode {
+			v.report(c, "doctype is not allowed in "+parentName(c))
+		}
+	}
+	v.models = append(v.models, anyModel)
+	return Continue
+}
+
+func (v *validator) Leave(c *Cursor) WalkAction {
+	v.models = v.models[:len(v.models)-1]
+	return Continue
+}
+
+// with retrieves the model extended by elems.
+func (m contentModel) with(elems map[string]bool) contentModel {
+	if len(elems) == 0 {
+		return m
+	}
+	if m.kind == modelAny || m.kind == modelText || m.kind == modelEmpty {
+		return m
+	}
+	ext := contentModel{kind: m.kind, elems: make(map[string]bool, len(m.elems)+len(elems))}
+	for e := range m.elems {
+		ext.elems[e] = true
+	}
+	for e := range elems {
+		ext.elems[e] = true
+	}
+	return ext
+}
+
+// checkNesting reports ancestors which can not contain the current element.
+func (v *validator) checkNesting(c *Cursor, cat int) {
+	n := c.Node()
+	for p := c.parent(); p != nil; p = p.parent() {
+		a := p.Node()
+		if !isElement(a) || a.Namespace != "" {
+			continue
+		}
+		rule, ok := notNested[a.Data]
+		if !ok {
+			continue
+		}
+		nested := cat&rule.cat != 0
+		for _, t := range rule.tags {
+			nested = nested || t == n.Data
+		}
+		if nested {
+			v.report(c, "<"+n.Data+"> can not be nested in <"+a.Data+">")
+			return
+		}
+	}
+}
+
+// parentName retrieves a description of the parent of c.
+func parentName(c *Cursor) string {
+	p := c.parent()
+	if p == nil {
+		return "root"
+	}
+	n := p.Node()
+	if isElement(n) {
+		return "<" + n.Data + ">"
+	}
+	return nodeTypeNames[n.Type]
+}
@@ -0,0 +1,290 @@
+package hck
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// classes marking changes in VisualDiff
+const (
+	classChanged  = "diff-changed"
+	classInserted = "diff-ins"
+	classDeleted  = "diff-del"
+)
+
+// VisualDiff retrieves a new tree showing the changes from a to b for rendering.
+//
+// Removed nodes are wrapped in <del>, inserted ones in <ins>,
+// changed text is compared word by word.
+// If too many words changed, they are marked as one deletion and insertion.
+// Where the content model does not allow <ins> and <del>, e.g. for <li> in <ul>
+// or <tr> in <tbody>, the element gets the class "diff-ins" or "diff-del"
+// and its content is wrapped instead.
+// Elements with changed attributes have the attributes of b and the class "diff-changed".
+// Text which can not be wrapped, e.g. in <title>, has the content of b
+// and its parent gets the class "diff-changed".
+//
+// Nodes are matched like in Diff. If a and b do not match,
+// a document containing both is retrieved.
+// The nodes of a and b are not modified or shared with the result.
+func VisualDiff(a, b *Node) *Node {
+	ns := visualPair(subtrees{}, anyModel, a, b)
+	if len(ns) == 1 {
+		return ns[0]
+	}
+	return Document(ns...)
+}
+
+// childModel retrieves the content model of the children of n in a parent with the model m.
+func childModel(m contentModel, n *Node) contentModel {
+	if n.Type != html.ElementNode {
+		return anyModel
+	}
+	s, _ := spec(n)
+	if s.model.kind == modelTransparent {
+		return m.with(s.model.elems)
+	}
+	return s.model
+}
+
+// wraps reports whether <ins> and <del> are allowed in the content model.
+func wraps(m contentModel) bool {
+	return m.allows(elementSpecs["ins"].cat, "ins")
+}
+
+// visualPair retrieves the nodes showing the changes from a to b in a parent with the model m.
+func visualPair(st subtrees, m contentModel, a, b *Node) Siblings {
+	if a == nil && b == nil {
+		return nil
+	}
+	if !similar(a, b) {
+		return append(marked(m, a, "del"), marked(m, b, "ins")...)
+	}
+	switch a.Type {
+	case html.TextNode:
+		return visualText(m, a.Data, b.Data)
+	case html.ElementNode, html.DocumentNode:
+	default:
+		return Siblings{b.DeepClone()}
+	}
+	n := &Node{
+		Namespace:  b.Namespace,
+		Data:       b.Data,
+		Attributes: append(Attributes(nil), b.Attributes...),
+		Type:       b.Type,
+	}
+	if !sameAttributes(a.Attributes, b.Attributes) {
+		n.Attributes.AddClass(classChanged)
+	}
+	cm := childModel(m, b)
+	if cm.kind == modelText {
+		if text(a) != text(b) {
+			n.Attributes.AddClass(classChanged)
+		}
+		n.Children = b.DeepClone().Children
+		return Siblings{n}
+	}
+	n.Children = visualChildren(st, cm, a.Children, b.Children)
+	return Siblings{n}
+}
+
+// visualChildren merges the children of matched nodes.
+func visualChildren(st subtrees, m contentModel, as, bs Siblings) Siblings {
+	var out Siblings
+	partner := matchSiblings(st, as, bs)
+	from := make([]int, len(bs))
+	for j := range from {
+		from[j] = -1
+	}
+	for i, j := range partner {
+		if j >= 0 {
+			from[j] = i
+		}
+	}
+	ai := 0
+	for j, bn := range bs {
+		i := from[j]
+		if i < 0 {
+			// deleted nodes in front of the insertion
+			for ; ai < len(as) && partner[ai] < 0; ai++ {
+				out = append(out, marked(m, as[ai], "del")...)
+			}
+			out = append(out, marked(m, bn, "ins")...)
+			continue
+		}
+		// deleted nodes in front of the match
+		for ; ai < i; ai++ {
+			if partner[ai] < 0 {
+				out = append(out, marked(m, as[ai], "del")...)
+			}
+		}
+		if ai == i {
+			ai++
+		}
+		out = append(out, visualPair(st, m, as[i], bn)...)
+	}
+	for ; ai < len(as); ai++ {
+		if partner[ai] < 0 {
+			out = append(out, marked(m, as[ai], "del")...)
+		}
+	}
+	return out
+}
+
+// marked retrieves a copy of n marked as inserted or deleted by tag "ins" or "del"
+// in a parent with the content model m.
+func marked(m contentModel, n *Node, tag string) Siblings {
+	if n == nil {
+		return nil
+	}
+	switch n.Type {
+	case html.TextNode:
+		if wraps(m) && !isSpace(n.Data) {
+			return Siblings{Tag(tag).Children(Text(n.Data)).Node()}
+		}
+		if tag == "del" && isSpace(n.Data) {
+			return nil
+		}
+		return Siblings{Text(n.Data)}
+	case html.ElementNode:
+	default:
+		if tag == "del" {
+			return nil
+		}
+		return Siblings{n.DeepClone()}
+	}
+	if wraps(m) {
+		return Siblings{Tag(tag).Children(n.DeepClone()).Node()}
+	}
+	c := &Node{
+		Namespace:  n.Namespace,
+		Data:       n.Data,
+		Attributes: append(Attributes(nil), n.Attributes...),
+		Type:       n.Type,
+	}
+	if tag == "del" {
+		c.Attributes.AddClass(classDeleted)
+	} else {
+		c.Attributes.AddClass(classInserted)
+	}
+	cm := childModel(m, n)
+	for _, ch := range n.Children {
+		c.Children = append(c.Children, marked(cm, ch, tag)...)
+	}
+	return Siblings{c}
+}
+
+// maxWordPairs limits the pairs of words compared in changed text.
+// Larger changes are shown as one deletion and one insertion.
+const maxWordPairs = 1 << 18
+
+// visualText retrieves text nodes showing the changes from a to b by words.
+func visualText(m contentModel, a, b string) Siblings {
+	if a == b || !wraps(m) {
+		return Siblings{Text(b)}
+	}
+	as, bs := words(a), words(b)
+	// only the words between the common prefix and suffix are compared
+	pre := 0
+	for pre < len(as) && pre < len(bs) && as[pre] == bs[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(as)-pre && suf < len(bs)-pre && as[len(as)-1-suf] == bs[len(bs)-1-suf] {
+		suf++
+	}
+	same, tail := strings.Join(as[:pre], ""), strings.Join(as[len(as)-suf:], "")
+	as, bs = as[pre:len(as)-suf], bs[pre:len(bs)-suf]
+	// lcs[i*w+j] is the length of the longest common subsequence of as[i:] and bs[j:]
+	w := len(bs) + 1
+	var lcs []int
+	if len(as)*len(bs) <= maxWordPairs {
+		lcs = make([]int, (len(as)+1)*w)
+		for i := len(as) - 1; i >= 0; i-- {
+			for j := len(bs) - 1; j >= 0; j-- {
+				switch k := i*w + j; {
+				case as[i] == bs[j]:
+					lcs[k] = lcs[k+w+1] + 1
+				case lcs[k+w] >= lcs[k+1]:
+					lcs[k] = lcs[k+w]
+				default:
+					lcs[k] = lcs[k+1]
+				}
+			}
+		}
+	}
+	var out Siblings
+	var del, ins string
+	flush := func() {
+		if del != "" {
+			out = append(out, Tag("del").Text(del).Node())
+			del = ""
+		}
+		if ins != "" {
+			out = append(out, Tag("ins").Text(ins).Node())
+			ins = ""
+		}
+	}
+	i, j := 0, 0
+	for i < len(as) || j < len(bs) {
+		switch {
+		case lcs != nil && i < len(as) && j < len(bs) && as[i] == bs[j]:
+			if del != "" || ins != "" {
+				flush()
+			}
+			same += as[i]
+			i++
+			j++
+			continue
+		case j == len(bs) || i < len(as) && (lcs == nil || lcs[(i+1)*w+j] >= lcs[i*w+j+1]):
+			del += as[i]
+			i++
+		default:
+			ins += bs[j]
+			j++
+		}
+		if same != "" {
+			out = append(out, Text(same))
+			same = ""
+		}
+	}
+	flush()
+	if same += tail; same != "" {
+		out = append(out, Text(same))
+	}
+	return out
+}
+
+// words splits s into runs of whitespace and other characters.
+func words(s string) []string {
+	var ws []string
+	start := 0
+	for i := 1; i <= len(s); i++ {
+		if i == len(s) || isSpace(s[i-1:i]) != isSpace(s[i:i+1]) {
+			ws = append(ws, s[start:i])
+			start = i
+		}
+	}
+	return ws
+}
+
+// sameAttributes reports whether as and bs contain the same attributes in any order.
+func sameAttributes(as, bs Attributes) bool {
+	if len(as) != len(bs) {
+		return false
+	}
+	for _, a := range as {
+		if b := bs.find(a.Key, a.Namespace); b == nil || b.Val != a.Val {
+			return false
+		}
+	}
+	return true
+}
+
+// text retrieves the concatenated text of the descendants of n.
+func text(n *Node) string {
+	var b strings.Builder
+	appendText(&b, n)
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package hck_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arnehormann/hck"
+)
+
+func TestVisualDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		a, b string
+		html string
+	}{
+		{"equal", `<p>same</p>`, `<p>same</p>`, `<p>same</p>`},
+		{"words", `<p>a b c</p>`, `<p>a x c</p>`, `<p>a <del>b</del><ins>x</ins> c</p>`},
+		{"inserted", `<p>a</p>`, `<p>a</p><p>b</p>`, `<p>a</p><ins><p>b</p></ins>`},
+		{"deleted", `<p>a</p><p>b</p>`, `<p>b</p>`, `<del><p>a</p></del><p>b</p>`},
+		{"list item", `<ul><li>a<li>b</ul>`, `<ul><li>a<li>c<li>b</ul>`,
+			`<ul><li>a</li><li class=diff-ins><ins>c</ins></li><li>b</li></ul>`},
+		{"table row", `<table><tr><td>1</table>`, `<table><tr><td>1<tr><td>2</table>`,
+			`<table><tbody><tr><td>1</td></tr><tr class=diff-ins><td class=diff-ins><ins>2</ins></td></tr></tbody></table>`},
+		{"attributes", `<p class=a>x</p>`, `<p class=b>x</p>`, `<p class="diff-changed b">x</p>`},
+		{"title", `<title>a</title>`, `<title>b</title>`, `<title class=diff-changed>b</title>`},
+	} {
+		a, b := parse(t, tc.a), parse(t, tc.b)
+		orig := render(t, a) + render(t, b)
+		if got, want := render(t, hck.VisualDiff(a, b)), render(t, parse(t, tc.html)); got != want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, want)
+		}
+		if render(t, a)+render(t, b) != orig {
+			t.Errorf("%s: VisualDiff modified its arguments", tc.name)
+		}
+	}
+}
+
+func TestVisualDiffRoot(t *testing.T) {
+	if got := render(t, hck.VisualDiff(tag("p"), tag("div"))); got != "<del><p></p></del><ins><div></div></ins>" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestVisualDiffLong(t *testing.T) {
+	// many changed words are one deletion and one insertion
+	var wa, wb []string
+	for i := 0; i < 1000; i++ {
+		wa = append(wa, "a"+strings.Repeat("x", i%9))
+		wb = append(wb, "b"+strings.Repeat("x", i%9))
+	}
+	a := parse(t, "<p>"+strings.Join(wa, " ")+"</p>")
+	b := parse(t, "<p>"+strings.Join(wb, " ")+"</p>")
+	d := hck.VisualDiff(a, b)
+	if n := len(d.Find(hck.MatchTag("del")).All()) + len(d.Find(hck.MatchTag("ins")).All()); n != 2 {
+		t.Errorf("got %d changes, want 2", n)
+	}
+	// long lists of siblings are matched
+	a, b = parse(t, rows(8000, nil)), parse(t, rows(8000, map[int]string{0: "first", 7999: "last"}))
+	d = hck.VisualDiff(a, b)
+	if n := len(d.Find(hck.MatchTag("ins")).All()); n != 2 {
+		t.Errorf("got %d insertions in rows, want 2", n)
+	}
+}
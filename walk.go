@@ -0,0 +1,85 @@
+package hck
+
+// WalkAction tells Walk how to continue after a node was entered or left.
+type WalkAction int
+
+const (
+	// Continue walks to the next node.
+	Continue WalkAction = iota
+	// SkipChildren skips the children of a node when returned by Enter.
+	// The node is still left.
+	SkipChildren
+	// Stop ends the walk immediately.
+	Stop
+)
+
+// Visitor is called by Walk when it enters a node before its children
+// and when it leaves it after its children.
+//
+// The cursor must not be moved or modified by the visitor,
+// but the current node and its children may be changed in Enter.
+// A copy of the cursor can be retrieved with Cursor.
+type Visitor interface {
+	Enter(c *Cursor) WalkAction
+	Leave(c *Cursor) WalkAction
+}
+
+// WalkFuncs is a Visitor calling its functions.
+// Missing functions continue the walk.
+type WalkFuncs struct {
+	OnEnter func(c *Cursor) WalkAction
+	OnLeave func(c *Cursor) WalkAction
+}
+
+func (w WalkFuncs) Enter(c *Cursor) WalkAction {
+	if w.OnEnter == nil {
+		return Continue
+	}
+	return w.OnEnter(c)
+}
+
+func (w WalkFuncs) Leave(c *Cursor) WalkAction {
+	if w.OnLeave == nil {
+		return Continue
+	}
+	return w.OnLeave(c)
+}
+
+// Walk visits n and all its descendants in document order.
+// It reports whether the walk was completed without being stopped.
+func (n *Node) Walk(v Visitor) bool {
+	if n == nil {
+		return true
+	}
+	return n.Cursor().Walk(v)
+}
+
+// Walk visits the current node and all its descendants in document order.
+// The path of c stays available to the visitor.
+// It reports whether the walk was completed without being stopped.
+func (c *Cursor) Walk(v Visitor) bool {
+	w := c.sub()
+	for {
+		switch v.Enter(w) {
+		case Stop:
+			return false
+		case Continue:
+			if w.FirstChild() != nil {
+				continue
+			}
+		}
+		// leave until a next sibling can be entered
+		for {
+			if v.Leave(w) == Stop {
+				return false
+			}
+			if w.Depth() <= w.top {
+				return true
+			}
+			if w.NextSibling() != nil {
+				break
+			}
+			w.Parent()
+		}
+	}
+}
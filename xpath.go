@@ -0,0 +1,1182 @@
+package hck
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// XPathError describes an error in an XPath expression.
+type XPathError struct {
+	Expr string
+	// Offset is the byte offset of the error in Expr
+	Offset int
+	Msg    string
+}
+
+func (e *XPathError) Error() string {
+	return fmt.Sprintf("xpath %q: %s at offset %d", e.Expr, e.Msg, e.Offset)
+}
+
+// XPath is a compiled XPath 1.0 expression.
+//
+// Element and attribute names are matched case-sensitively.
+// Names without prefix match elements in all namespaces, names with a prefix
+// match elements in the namespace of the same name (e.g. "svg:circle").
+// Document type nodes are invisible.
+// Variables are not supported.
+type XPath struct {
+	expr string
+	root xexpr
+}
+
+// XPathNode is a node selected by an XPath expression.
+type XPathNode struct {
+	// Path to the node, for attributes to the element carrying it
+	Path Path
+	// Attr is the selected attribute or nil
+	Attr *html.Attribute
+}
+
+// CompileXPath parses an XPath 1.0 expression.
+func CompileXPath(expr string) (*XPath, error) {
+	p := &xpathParser{expr: expr}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != xtEOF {
+		return nil, p.errorf(t.pos, "unexpected %q", t.val)
+	}
+	return &XPath{expr: expr, root: e}, nil
+}
+
+// MustCompileXPath is like CompileXPath but panics if the expression can not be parsed.
+func MustCompileXPath(expr string) *XPath {
+	x, err := CompileXPath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+func (x *XPath) String() string {
+	return x.expr
+}
+
+// Evaluate the expression with n as the context node.
+// It retrieves a bool, float64, string or []XPathNode in document order.
+func (x *XPath) Evaluate(n *Node) (interface{}, error) {
+	if n == nil {
+		return nil, x.errorf(0, "no context node")
+	}
+	return x.EvaluateCursor(n.Cursor())
+}
+
+// EvaluateCursor is like Evaluate with the current node of c as the context node.
+// The path of c is available to the parent and ancestor axes, its first node is the root.
+func (x *XPath) EvaluateCursor(c *Cursor) (interface{}, error) {
+	v, err := x.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := v.([]xnode)
+	if !ok {
+		return v, nil
+	}
+	res := make([]XPathNode, len(ns))
+	for i, n := range ns {
+		res[i].Path = n.path
+		if n.attr >= 0 {
+			res[i].Attr = &n.node().Attributes[n.attr]
+		}
+	}
+	return res, nil
+}
+
+// Select evaluates the expression with n as the context node
+// and retrieves the paths to the selected nodes in document order.
+// Attribute nodes are skipped, use Evaluate to retrieve them.
+// It is an error if the expression does not evaluate to a node-set.
+func (x *XPath) Select(n *Node) ([]Path, error) {
+	if n == nil {
+		return nil, x.errorf(0, "no context node")
+	}
+	return x.SelectCursor(n.Cursor())
+}
+
+// SelectCursor is like Select with the current node of c as the context node.
+func (x *XPath) SelectCursor(c *Cursor) ([]Path, error) {
+	v, err := x.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := v.([]xnode)
+	if !ok {
+		return nil, x.errorf(0, "expression does not select nodes")
+	}
+	ps := make([]Path, 0, len(ns))
+	for _, n := range ns {
+		if n.attr < 0 {
+			ps = append(ps, n.path)
+		}
+	}
+	return ps, nil
+}
+
+func (x *XPath) errorf(offset int, format string, args ...interface{}) error {
+	return &XPathError{
+		Expr:   x.expr,
+		Offset: offset,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func (x *XPath) eval(c *Cursor) (xvalue, error) {
+	if c == nil {
+		return nil, x.errorf(0, "no context node")
+	}
+	path := c.Path()
+	pos := make([]int, len(path))
+	for i := 1; i < len(path); i++ {
+		pos[i] = path[i-1].Children.Index(path[i])
+	}
+	ctx := &xcontext{
+		node: xnode{path: path, pos: pos, attr: -1},
+		size: 1,
+		pos:  1,
+	}
+	v, err := x.root.eval(ctx)
+	if err != nil {
+		if e, ok := err.(*XPathError); ok && e.Expr == "" {
+			e.Expr = x.expr
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+// xvalue is a bool, float64, string or []xnode sorted in document order.
+type xvalue interface{}
+
+// xnode is a node in an XPath node-set.
+type xnode struct {
+	path Path
+	// index of each node in its parent's children
+	pos []int
+	// index of the attribute in the node or -1
+	attr int
+}
+
+func (x xnode) node() *Node {
+	return x.path[len(x.path)-1]
+}
+
+func (x xnode) child(n *Node, i int) xnode {
+	d := len(x.path)
+	return xnode{
+		path: append(x.path[:d:d], n),
+		pos:  append(x.pos[:d:d], i),
+		attr: -1,
+	}
+}
+
+func (x xnode) parent() (xnode, bool) {
+	if x.attr >= 0 {
+		return xnode{path: x.path, pos: x.pos, attr: -1}, true
+	}
+	d := len(x.path) - 1
+	if d <= 0 {
+		return x, false
+	}
+	return xnode{path: x.path[:d:d], pos: x.pos[:d:d], attr: -1}, true
+}
+
+// compare the document order of two nodes.
+func (x xnode) compare(y xnode) int {
+	for i := 1; i < len(x.pos) && i < len(y.pos); i++ {
+		if d := x.pos[i] - y.pos[i]; d != 0 {
+			return d
+		}
+	}
+	if d := len(x.pos) - len(y.pos); d != 0 {
+		return d
+	}
+	return x.attr - y.attr
+}
+
+// visible reports whether n is part of the XPath data model.
+func visible(n *Node) bool {
+	return n != nil && n.Type != html.DoctypeNode
+}
+
+// sortNodes sorts nodes in document order and removes duplicates.
+func sortNodes(ns []xnode) []xnode {
+	if len(ns) < 2 {
+		return ns
+	}
+	sort.SliceStable(ns, func(i, j int) bool {
+		return ns[i].compare(ns[j]) < 0
+	})
+	res := ns[:1]
+	for _, n := range ns[1:] {
+		if n.compare(res[len(res)-1]) != 0 {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+type xcontext struct {
+	node      xnode
+	pos, size int
+}
+
+type xexpr interface {
+	eval(ctx *xcontext) (xvalue, error)
+}
+
+type xliteral string
+
+func (e xliteral) eval(*xcontext) (xvalue, error) {
+	return string(e), nil
+}
+
+type xnumber float64
+
+func (e xnumber) eval(*xcontext) (xvalue, error) {
+	return float64(e), nil
+}
+
+type xnegate struct {
+	e xexpr
+}
+
+func (e xnegate) eval(ctx *xcontext) (xvalue, error) {
+	v, err := e.e.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return -toNumber(v), nil
+}
+
+type xbinary struct {
+	op   string
+	l, r xexpr
+	pos  int
+}
+
+func (e *xbinary) eval(ctx *xcontext) (xvalue, error) {
+	l, err := e.l.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// and and or short-circuit
+	switch e.op {
+	case "and":
+		if !toBoolean(l) {
+			return false, nil
+		}
+	case "or":
+		if toBoolean(l) {
+			return true, nil
+		}
+	}
+	r, err := e.r.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "and", "or":
+		return toBoolean(r), nil
+	case "|":
+		ln, lok := l.([]xnode)
+		rn, rok := r.([]xnode)
+		if !lok || !rok {
+			return nil, &XPathError{Offset: e.pos, Msg: "union of non node-sets"}
+		}
+		return sortNodes(append(ln[:len(ln):len(ln)], rn...)), nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		return compareValues(e.op, l, r), nil
+	}
+	a, b := toNumber(l), toNumber(r)
+	switch e.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "div":
+		return a / b, nil
+	}
+	// mod truncates like the % operator in Java and ECMAScript
+	return math.Mod(a, b), nil
+}
+
+type xfunction struct {
+	name string
+	f    *xfunc
+	args []xexpr
+	pos  int
+}
+
+func (e *xfunction) eval(ctx *xcontext) (xvalue, error) {
+	args := make([]xvalue, len(e.args))
+	for i, a := range e.args {
+		v, err := a.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := e.f.call(ctx, args)
+	if err != nil {
+		return nil, &XPathError{Offset: e.pos, Msg: e.name + ": " + err.Error()}
+	}
+	return v, nil
+}
+
+// xfilter is a filter expression followed by an optional location path.
+type xfilter struct {
+	e     xexpr
+	preds []xexpr
+	steps []*xstep
+	pos   int
+}
+
+func (e *xfilter) eval(ctx *xcontext) (xvalue, error) {
+	v, err := e.e.eval(ctx)
+	if err != nil || len(e.preds) == 0 && len(e.steps) == 0 {
+		return v, err
+	}
+	ns, ok := v.([]xnode)
+	if !ok {
+		return nil, &XPathError{Offset: e.pos, Msg: "expression does not select nodes"}
+	}
+	for _, p := range e.preds {
+		if ns, err = filterNodes(ctx, ns, p); err != nil {
+			return nil, err
+		}
+	}
+	return evalSteps(ctx, ns, e.steps)
+}
+
+type xlocation struct {
+	absolute bool
+	steps    []*xstep
+}
+
+func (e *xlocation) eval(ctx *xcontext) (xvalue, error) {
+	n := ctx.node
+	if e.absolute {
+		n = xnode{path: n.path[:1:1], pos: n.pos[:1:1], attr: -1}
+	}
+	return evalSteps(ctx, []xnode{n}, e.steps)
+}
+
+func evalSteps(ctx *xcontext, ns []xnode, steps []*xstep) (xvalue, error) {
+	for _, s := range steps {
+		var res []xnode
+		for _, n := range ns {
+			sel := s.axis(n, s.test)
+			for _, p := range s.preds {
+				var err error
+				if sel, err = filterNodes(ctx, sel, p); err != nil {
+					return nil, err
+				}
+			}
+			res = append(res, sel...)
+		}
+		ns = sortNodes(res)
+	}
+	return ns, nil
+}
+
+// filterNodes retrieves the nodes for which the predicate is true.
+func filterNodes(ctx *xcontext, ns []xnode, pred xexpr) ([]xnode, error) {
+	var res []xnode
+	pctx := *ctx
+	pctx.size = len(ns)
+	for i, n := range ns {
+		pctx.node = n
+		pctx.pos = i + 1
+		v, err := pred.eval(&pctx)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := v.(float64); ok {
+			if f == float64(pctx.pos) {
+				res = append(res, n)
+			}
+			continue
+		}
+		if toBoolean(v) {
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
+
+type xstep struct {
+	axis  xaxis
+	test  xtest
+	preds []xexpr
+}
+
+// xtest reports whether a node passes a node test.
+type xtest func(n xnode) bool
+
+// nameTest matches the principal node type of an axis by name.
+// Empty local names and namespaces match all names and namespaces.
+func nameTest(attr bool, namespace, local string, anyNamespace bool) xtest {
+	return func(x xnode) bool {
+		n := x.node()
+		if attr {
+			if x.attr < 0 {
+				return false
+			}
+			a := &n.Attributes[x.attr]
+			return (anyNamespace || a.Namespace == namespace) &&
+				(local == "" || a.Key == local)
+		}
+		return x.attr < 0 && n.Type == html.ElementNode &&
+			(anyNamespace || n.Namespace == namespace) &&
+			(local == "" || n.Data == local)
+	}
+}
+
+func typeTest(t html.NodeType) xtest {
+	return func(x xnode) bool {
+		return x.attr < 0 && x.node().Type == t
+	}
+}
+
+func anyTest(xnode) bool {
+	return true
+}
+
+func noTest(xnode) bool {
+	return false
+}
+
+// xaxis retrieves the nodes on an axis passing a test in axis order.
+type xaxis func(n xnode, test xtest) []xnode
+
+var xaxes = map[string]xaxis{
+	"ancestor":           ancestorAxis(false),
+	"ancestor-or-self":   ancestorAxis(true),
+	"attribute":          attributeAxis,
+	"child":              childAxis,
+	"descendant":         descendantAxis(false),
+	"descendant-or-self": descendantAxis(true),
+	"following":          followingAxis,
+	"following-sibling":  siblingAxis(1),
+	"namespace":          namespaceAxis,
+	"parent":             parentAxis,
+	"preceding":          precedingAxis,
+	"preceding-sibling":  siblingAxis(-1),
+	"self":               selfAxis,
+}
+
+func selfAxis(x xnode, test xtest) []xnode {
+	if test(x) {
+		return []xnode{x}
+	}
+	return nil
+}
+
+func childAxis(x xnode, test xtest) []xnode {
+	if x.attr >= 0 {
+		return nil
+	}
+	var res []xnode
+	for i, c := range x.node().Children {
+		if !visible(c) {
+			continue
+		}
+		if cx := x.child(c, i); test(cx) {
+			res = append(res, cx)
+		}
+	}
+	return res
+}
+
+func descendantAxis(self bool) xaxis {
+	return func(x xnode, test xtest) []xnode {
+		var res []xnode
+		if self && test(x) {
+			res = append(res, x)
+		}
+		if x.attr >= 0 {
+			return res
+		}
+		return appendDescendants(res, x, test)
+	}
+}
+
+// appendDescendants appends the descendants of x in document order.
+func appendDescendants(res []xnode, x xnode, test xtest) []xnode {
+	for i, c := range x.node().Children {
+		if !visible(c) {
+			continue
+		}
+		cx := x.child(c, i)
+		if test(cx) {
+			res = append(res, cx)
+		}
+		res = appendDescendants(res, cx, test)
+	}
+	return res
+}
+
+// appendDescendantsReverse appends the descendants of x in reverse document order.
+func appendDescendantsReverse(res []xnode, x xnode, test xtest) []xnode {
+	cs := x.node().Children
+	for i := len(cs) - 1; i >= 0; i-- {
+		c := cs[i]
+		if !visible(c) {
+			continue
+		}
+		cx := x.child(c, i)
+		res = appendDescendantsReverse(res, cx, test)
+		if test(cx) {
+			res = append(res, cx)
+		}
+	}
+	return res
+}
+
+func parentAxis(x xnode, test xtest) []xnode {
+	if p, ok := x.parent(); ok && test(p) {
+		return []xnode{p}
+	}
+	return nil
+}
+
+func ancestorAxis(self bool) xaxis {
+	return func(x xnode, test xtest) []xnode {
+		var res []xnode
+		if self && test(x) {
+			res = append(res, x)
+		}
+		for p, ok := x.parent(); ok; p, ok = p.parent() {
+			if test(p) {
+				res = append(res, p)
+			}
+		}
+		return res
+	}
+}
+
+func siblingAxis(step int) xaxis {
+	return func(x xnode, test xtest) []xnode {
+		p, ok := x.parent()
+		if x.attr >= 0 || !ok {
+			return nil
+		}
+		var res []xnode
+		cs := p.node().Children
+		for i := x.pos[len(x.pos)-1] + step; 0 <= i && i < len(cs); i += step {
+			if !visible(cs[i]) {
+				continue
+			}
+			if sx := p.child(cs[i], i); test(sx) {
+				res = append(res, sx)
+			}
+		}
+		return res
+	}
+}
+
+func followingAxis(x xnode, test xtest) []xnode {
+	var res []xnode
+	if x.attr >= 0 {
+		// the children of an element follow its attributes
+		x, _ = x.parent()
+		res = appendDescendants(res, x, test)
+	}
+	for ; ; x, _ = x.parent() {
+		p, ok := x.parent()
+		if !ok {
+			return res
+		}
+		cs := p.node().Children
+		for i := x.pos[len(x.pos)-1] + 1; i < len(cs); i++ {
+			if !visible(cs[i]) {
+				continue
+			}
+			sx := p.child(cs[i], i)
+			if test(sx) {
+				res = append(res, sx)
+			}
+			res = appendDescendants(res, sx, test)
+		}
+	}
+}
+
+func precedingAxis(x xnode, test xtest) []xnode {
+	var res []xnode
+	if x.attr >= 0 {
+		x, _ = x.parent()
+	}
+	for ; ; x, _ = x.parent() {
+		p, ok := x.parent()
+		if !ok {
+			return res
+		}
+		cs := p.node().Children
+		for i := x.pos[len(x.pos)-1] - 1; i >= 0; i-- {
+			if !visible(cs[i]) {
+				continue
+			}
+			sx := p.child(cs[i], i)
+			res = appendDescendantsReverse(res, sx, test)
+			if test(sx) {
+				res = append(res, sx)
+			}
+		}
+	}
+}
+
+func attributeAxis(x xnode, test xtest) []xnode {
+	n := x.node()
+	if x.attr >= 0 || n.Type != html.ElementNode {
+		return nil
+	}
+	var res []xnode
+	for i := range n.Attributes {
+		ax := xnode{path: x.path, pos: x.pos, attr: i}
+		if test(ax) {
+			res = append(res, ax)
+		}
+	}
+	return res
+}
+
+func namespaceAxis(xnode, xtest) []xnode {
+	return nil
+}
+
+// token kinds
+const (
+	xtEOF = iota
+	xtOperator
+	xtPunct
+	xtName
+	xtFunction
+	xtNodeType
+	xtAxis
+	xtLiteral
+	xtNumber
+	xtVariable
+)
+
+type xtoken struct {
+	kind int
+	val  string
+	pos  int
+}
+
+type xpathParser struct {
+	expr   string
+	tokens []xtoken
+	i      int
+}
+
+func (p *xpathParser) errorf(offset int, format string, args ...interface{}) error {
+	return &XPathError{
+		Expr:   p.expr,
+		Offset: offset,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func (p *xpathParser) peek() xtoken {
+	return p.tokens[p.i]
+}
+
+func (p *xpathParser) next() xtoken {
+	t := p.tokens[p.i]
+	if t.kind != xtEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is an operator or punctuation with value val.
+func (p *xpathParser) accept(val string) bool {
+	if t := p.peek(); (t.kind == xtOperator || t.kind == xtPunct) && t.val == val {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *xpathParser) expect(val string) error {
+	if !p.accept(val) {
+		t := p.peek()
+		if t.kind == xtEOF {
+			return p.errorf(t.pos, "expected %q", val)
+		}
+		return p.errorf(t.pos, "expected %q, found %q", val, t.val)
+	}
+	return nil
+}
+
+func isXMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isNCNameStart(r rune) bool {
+	return r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r >= 0x80
+}
+
+func isNCNameChar(r rune) bool {
+	return isNCNameStart(r) || r == '-' || r == '.' || '0' <= r && r <= '9'
+}
+
+// scanNCName retrieves the length of the NCName starting at s.
+func scanNCName(s string) int {
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if i == 0 && !isNCNameStart(r) || !isNCNameChar(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// tokenize splits the expression into tokens, the special lexical rules
+// of XPath 1.0 section 3.7 are applied to names and '*'.
+func (p *xpathParser) tokenize() error {
+	s := p.expr
+	i := 0
+	for {
+		for i < len(s) && isXMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			p.tokens = append(p.tokens, xtoken{kind: xtEOF, pos: i})
+			return nil
+		}
+		// an operator is expected if the previous token can end an operand
+		operator := false
+		if n := len(p.tokens); n > 0 {
+			switch prev := p.tokens[n-1]; prev.kind {
+			case xtOperator:
+			case xtPunct:
+				operator = prev.val == ")" || prev.val == "]" || prev.val == "." || prev.val == ".."
+			default:
+				operator = true
+			}
+		}
+		start := i
+		c := s[i]
+		switch {
+		case c == '(' || c == ')' || c == '[' || c == ']' || c == ',' || c == '@':
+			p.tokens = append(p.tokens, xtoken{kind: xtPunct, val: s[i : i+1], pos: i})
+			i++
+		case c == ':' && strings.HasPrefix(s[i:], "::"):
+			p.tokens = append(p.tokens, xtoken{kind: xtPunct, val: "::", pos: i})
+			i += 2
+		case c == '.' && strings.HasPrefix(s[i:], ".."):
+			p.tokens = append(p.tokens, xtoken{kind: xtPunct, val: "..", pos: i})
+			i += 2
+		case c == '.' && (i+1 >= len(s) || s[i+1] < '0' || s[i+1] > '9'):
+			p.tokens = append(p.tokens, xtoken{kind: xtPunct, val: ".", pos: i})
+			i++
+		case '0' <= c && c <= '9' || c == '.':
+			for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+				i++
+			}
+			if i < len(s) && s[i] == '.' {
+				i++
+				for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+					i++
+				}
+			}
+			p.tokens = append(p.tokens, xtoken{kind: xtNumber, val: s[start:i], pos: start})
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return p.errorf(i, "unterminated literal")
+			}
+			i += end + 2
+			p.tokens = append(p.tokens, xtoken{kind: xtLiteral, val: s[start+1 : i-1], pos: start})
+		case c == '/' || c == '|' || c == '+' || c == '-' || c == '=' || c == '<' || c == '>' || c == '!':
+			op := s[i : i+1]
+			if i+1 < len(s) && (c == '/' && s[i+1] == '/' || (c == '<' || c == '>' || c == '!') && s[i+1] == '=') {
+				op = s[i : i+2]
+			}
+			if op == "!" {
+				return p.errorf(i, "unexpected '!'")
+			}
+			p.tokens = append(p.tokens, xtoken{kind: xtOperator, val: op, pos: i})
+			i += len(op)
+		case c == '*':
+			kind := xtName
+			if operator {
+				kind = xtOperator
+			}
+			p.tokens = append(p.tokens, xtoken{kind: kind, val: "*", pos: i})
+			i++
+		case c == '$':
+			n := scanNCName(s[i+1:])
+			if n == 0 {
+				return p.errorf(i, "expected variable name")
+			}
+			i += n + 1
+			p.tokens = append(p.tokens, xtoken{kind: xtVariable, val: s[start+1 : i], pos: start})
+		default:
+			n := scanNCName(s[i:])
+			if n == 0 {
+				r, _ := utf8.DecodeRuneInString(s[i:])
+				return p.errorf(i, "unexpected %q", r)
+			}
+			i += n
+			if operator {
+				switch name := s[start:i]; name {
+				case "and", "or", "mod", "div":
+					p.tokens = append(p.tokens, xtoken{kind: xtOperator, val: name, pos: start})
+					continue
+				}
+				return p.errorf(start, "expected operator, found %q", s[start:i])
+			}
+			// QName or prefix:*
+			if i+1 < len(s) && s[i] == ':' && s[i+1] != ':' {
+				if s[i+1] == '*' {
+					i += 2
+				} else if n := scanNCName(s[i+1:]); n > 0 {
+					i += n + 1
+				}
+			}
+			kind := xtName
+			j := i
+			for j < len(s) && isXMLSpace(s[j]) {
+				j++
+			}
+			name := s[start:i]
+			switch {
+			case strings.HasPrefix(s[j:], "::"):
+				kind = xtAxis
+			case strings.HasPrefix(s[j:], "("):
+				kind = xtFunction
+				switch name {
+				case "comment", "text", "processing-instruction", "node":
+					kind = xtNodeType
+				}
+			}
+			p.tokens = append(p.tokens, xtoken{kind: kind, val: name, pos: start})
+		}
+	}
+}
+
+func (p *xpathParser) parseExpr() (xexpr, error) {
+	return p.parseBinary(0)
+}
+
+// binary operators by increasing precedence
+var xoperators = [][]string{
+	{"or"},
+	{"and"},
+	{"=", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "div", "mod"},
+}
+
+func (p *xpathParser) parseBinary(level int) (xexpr, error) {
+	if level >= len(xoperators) {
+		return p.parseUnary()
+	}
+	l, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != xtOperator || !containsString(xoperators[level], t.val) {
+			return l, nil
+		}
+		p.next()
+		r, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = &xbinary{op: t.val, l: l, r: r, pos: t.pos}
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *xpathParser) parseUnary() (xexpr, error) {
+	if p.accept("-") {
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return xnegate{e}, nil
+	}
+	l, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept("|") {
+			return l, nil
+		}
+		r, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		l = &xbinary{op: "|", l: l, r: r, pos: t.pos}
+	}
+}
+
+func (p *xpathParser) parsePath() (xexpr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == xtPunct && t.val == "(",
+		t.kind == xtLiteral, t.kind == xtNumber, t.kind == xtFunction, t.kind == xtVariable:
+	default:
+		return p.parseLocation()
+	}
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	f := &xfilter{e: e, pos: t.pos}
+	if f.preds, err = p.parsePredicates(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == xtOperator && (t.val == "/" || t.val == "//") {
+		if f.steps, err = p.parseSteps(); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.preds) == 0 && len(f.steps) == 0 {
+		return e, nil
+	}
+	return f, nil
+}
+
+func (p *xpathParser) parsePrimary() (xexpr, error) {
+	t := p.next()
+	switch t.kind {
+	case xtLiteral:
+		return xliteral(t.val), nil
+	case xtNumber:
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, p.errorf(t.pos, "invalid number %q", t.val)
+		}
+		return xnumber(f), nil
+	case xtVariable:
+		return nil, p.errorf(t.pos, "variables are not supported")
+	case xtFunction:
+		return p.parseFunction(t)
+	}
+	// '('
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (p *xpathParser) parseFunction(t xtoken) (xexpr, error) {
+	f, ok := xfuncs[t.val]
+	if !ok {
+		return nil, p.errorf(t.pos, "unknown function %q", t.val)
+	}
+	p.next() // '('
+	var args []xexpr
+	if !p.accept(")") {
+		for {
+			a, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(args) < f.min || f.max >= 0 && len(args) > f.max {
+		return nil, p.errorf(t.pos, "wrong number of arguments for %s()", t.val)
+	}
+	return &xfunction{name: t.val, f: f, args: args, pos: t.pos}, nil
+}
+
+func (p *xpathParser) parsePredicates() ([]xexpr, error) {
+	var preds []xexpr
+	for p.accept("[") {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		preds = append(preds, e)
+	}
+	return preds, nil
+}
+
+func (p *xpathParser) parseLocation() (xexpr, error) {
+	loc := &xlocation{}
+	t := p.peek()
+	if t.kind == xtOperator && (t.val == "/" || t.val == "//") {
+		loc.absolute = true
+		if t.val == "/" {
+			p.next()
+			// a lone "/" selects the root
+			if !p.startsStep() {
+				return loc, nil
+			}
+			p.i--
+		}
+		steps, err := p.parseSteps()
+		if err != nil {
+			return nil, err
+		}
+		loc.steps = steps
+		return loc, nil
+	}
+	s, err := p.parseStep()
+	if err != nil {
+		return nil, err
+	}
+	loc.steps = append(loc.steps, s)
+	if t := p.peek(); t.kind == xtOperator && (t.val == "/" || t.val == "//") {
+		steps, err := p.parseSteps()
+		if err != nil {
+			return nil, err
+		}
+		loc.steps = append(loc.steps, steps...)
+	}
+	return loc, nil
+}
+
+// startsStep reports whether the next token starts a step.
+func (p *xpathParser) startsStep() bool {
+	switch t := p.peek(); t.kind {
+	case xtName, xtAxis, xtNodeType:
+		return true
+	case xtPunct:
+		return t.val == "@" || t.val == "." || t.val == ".."
+	}
+	return false
+}
+
+// parseSteps parses steps each preceded by "/" or "//".
+func (p *xpathParser) parseSteps() ([]*xstep, error) {
+	var steps []*xstep
+	for {
+		t := p.peek()
+		if t.kind != xtOperator || t.val != "/" && t.val != "//" {
+			return steps, nil
+		}
+		p.next()
+		if t.val == "//" {
+			steps = append(steps, &xstep{
+				axis: xaxes["descendant-or-self"],
+				test: anyTest,
+			})
+		}
+		s, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, s)
+	}
+}
+
+func (p *xpathParser) parseStep() (*xstep, error) {
+	switch {
+	case p.accept("."):
+		return &xstep{axis: selfAxis, test: anyTest}, nil
+	case p.accept(".."):
+		return &xstep{axis: parentAxis, test: anyTest}, nil
+	}
+	axis, attr := "child", false
+	if p.accept("@") {
+		axis, attr = "attribute", true
+	} else if t := p.peek(); t.kind == xtAxis {
+		p.next()
+		axis = t.val
+		if _, ok := xaxes[axis]; !ok {
+			return nil, p.errorf(t.pos, "unknown axis %q", axis)
+		}
+		attr = axis == "attribute"
+		p.next() // '::'
+	}
+	s := &xstep{axis: xaxes[axis]}
+	t := p.next()
+	switch t.kind {
+	case xtName:
+		local, namespace, anyNamespace := t.val, "", true
+		if i := strings.IndexByte(local, ':'); i >= 0 {
+			namespace, local, anyNamespace = local[:i], local[i+1:], false
+		}
+		if local == "*" {
+			local = ""
+		}
+		s.test = nameTest(attr, namespace, local, anyNamespace)
+	case xtNodeType:
+		p.next() // '('
+		switch t.val {
+		case "node":
+			s.test = anyTest
+		case "text":
+			s.test = typeTest(html.TextNode)
+		case "comment":
+			s.test = typeTest(html.CommentNode)
+		default:
+			// processing instructions are not represented
+			s.test = noTest
+			if p.peek().kind == xtLiteral {
+				p.next()
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	case xtEOF:
+		return nil, p.errorf(t.pos, "expected node test")
+	default:
+		return nil, p.errorf(t.pos, "expected node test, found %q", t.val)
+	}
+	preds, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	s.preds = preds
+	return s, nil
+}
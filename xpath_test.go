@@ -0,0 +1,155 @@
+package hck_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/arnehormann/hck"
+	"golang.org/x/net/html"
+)
+
+const xpathDoc = `<!DOCTYPE html><html><body><div id=main><h2>One</h2><p class=a>Hello <b>big</b> world</p>` +
+	`<p lang=en-US>Two</p><a href="/x">link</a><a href="/y">l2</a></div><!--c-->` +
+	`<ul><li>1</li><li>2</li><li>3</li></ul></body></html>`
+
+// text retrieves the concatenated text of n and its descendants.
+func text(n *hck.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var s strings.Builder
+	for _, c := range n.Children {
+		if c.Type != html.CommentNode {
+			s.WriteString(text(c))
+		}
+	}
+	return s.String()
+}
+
+// xpathValue formats the result of XPath.Evaluate.
+// Node-sets are formatted as the list of the string values of the nodes.
+func xpathValue(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []hck.XPathNode:
+		s := make([]string, len(v))
+		for i, n := range v {
+			if n.Attr != nil {
+				s[i] = n.Attr.Val
+			} else {
+				s[i] = text(n.Path.Node())
+			}
+		}
+		return "[" + strings.Join(s, " ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+func TestXPath(t *testing.T) {
+	doc, err := hck.Parse(strings.NewReader(xpathDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		// location paths
+		{"//p[2]/text()", "[Two]"},
+		{"//div/p[@class]/b", "[big]"},
+		{"(//li)[2]", "[2]"},
+		{"//li[last()-1]", "[2]"},
+		{"//ul/li[2]/..//li[3]", "[3]"},
+		{"//*[self::h2 or self::b]", "[One big]"},
+		{"//p[lang('en')]", "[Two]"},
+		{"//a/@href", "[/x /y]"},
+		// axes
+		{"//li[. = '2']/following-sibling::*", "[3]"},
+		{"//li[3]/preceding-sibling::li[1]", "[2]"},
+		{"name(//b/parent::*)", "p"},
+		{"count(//b/ancestor::*)", "4"},
+		{"string(//b/ancestor::div/@id)", "main"},
+		{"count(//h2/following::*)", "9"},
+		{"count(//li[1]/preceding::*)", "8"},
+		{"count(//div//text())", "7"},
+		{"count(//comment())", "1"},
+		{"count(/)", "1"},
+		{"local-name(/*)", "html"},
+		// functions
+		{"count(//p)", "2"},
+		{"string(//p[1])", "Hello big world"},
+		{"count(//li[position() > 1])", "2"},
+		{"count(//*[@class='a'] | //h2)", "2"},
+		{"count(id('main')/*)", "5"},
+		{"contains(//p[1], 'big')", "true"},
+		{"starts-with(//h2, 'O')", "true"},
+		{"substring('12345', 2, 3)", "234"},
+		{"substring('12345', 1.5, 2.6)", "234"},
+		{"substring('12345', 0, 3)", "12"},
+		{"substring-before('a/b', '/')", "a"},
+		{"substring-after('a/b', '/')", "b"},
+		{"normalize-space('  a   b ')", "a b"},
+		{"translate('bar', 'abc', 'ABC')", "BAr"},
+		{"string-length('äb')", "2"},
+		{"concat('a', 1, true())", "a1true"},
+		{"sum(//li)", "6"},
+		// operators and numbers
+		{"1 + 2 * 3", "7"},
+		{"2 * 3 * 1", "6"},
+		{"7 mod 3", "1"},
+		{"-7 div 2", "-3.5"},
+		{"string(round(-0.5))", "0"},
+		{"round(2.5)", "3"},
+		{"floor(2.5) = 2", "true"},
+		{"number('1e3')", "NaN"},
+		{"count(//li) > 2 and not(false())", "true"},
+	} {
+		x, err := hck.CompileXPath(tc.expr)
+		if err != nil {
+			t.Errorf("CompileXPath(%q): %v", tc.expr, err)
+			continue
+		}
+		v, err := x.Evaluate(doc)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		if got := xpathValue(v); got != tc.want {
+			t.Errorf("%s = %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestXPathCursor(t *testing.T) {
+	doc, err := hck.Parse(strings.NewReader(xpathDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps, err := hck.MustCompileXPath("//li").Select(doc)
+	if err != nil || len(ps) != 3 || ps[2].Node().Data != "li" {
+		t.Errorf("Select(//li) = %v, %v", ps, err)
+	}
+	c := doc.Cursor()
+	c.Seek(hck.MatchTag("b"))
+	v, err := hck.MustCompileXPath("string(ancestor::div/@id)").EvaluateCursor(c)
+	if v != "main" || err != nil {
+		t.Errorf("EvaluateCursor = %v, %v, want main", v, err)
+	}
+	if _, err := hck.MustCompileXPath("count(//li)").Select(doc); err == nil {
+		t.Error("Select of a number succeeded")
+	}
+}
+
+func TestCompileXPathError(t *testing.T) {
+	for _, expr := range []string{"//", "a[", "count(", "foo()", "$x", "a b", "1 +", "child::", "bogus::a", "'abc"} {
+		_, err := hck.CompileXPath(expr)
+		var xe *hck.XPathError
+		if !errors.As(err, &xe) {
+			t.Errorf("CompileXPath(%q): got error %v, want an XPathError", expr, err)
+		}
+	}
+}
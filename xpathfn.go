@@ -0,0 +1,478 @@
+package hck
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// namespaces maps the namespaces used by golang.org/x/net/html to their URIs.
+var namespaces = map[string]string{
+	"":      "http://www.w3.org/1999/xhtml",
+	"math":  "http://www.w3.org/1998/Math/MathML",
+	"svg":   "http://www.w3.org/2000/svg",
+	"xlink": "http://www.w3.org/1999/xlink",
+	"xml":   "http://www.w3.org/XML/1998/namespace",
+	"xmlns": "http://www.w3.org/2000/xmlns/",
+}
+
+// stringValue retrieves the XPath string-value of a node.
+func (x xnode) stringValue() string {
+	n := x.node()
+	if x.attr >= 0 {
+		return n.Attributes[x.attr].Val
+	}
+	switch n.Type {
+	case html.TextNode, html.CommentNode:
+		return n.Data
+	}
+	var b strings.Builder
+	appendText(&b, n)
+	return b.String()
+}
+
+// appendText appends the data of all descendant text nodes.
+func appendText(b *strings.Builder, n *Node) {
+	for _, c := range n.Children {
+		if c == nil {
+			continue
+		}
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+			continue
+		}
+		appendText(b, c)
+	}
+}
+
+func toBoolean(v xvalue) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	case []xnode:
+		return len(v) > 0
+	}
+	return false
+}
+
+func toNumber(v xvalue) float64 {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	case string:
+		return parseNumber(v)
+	case []xnode:
+		return parseNumber(toString(v))
+	}
+	return math.NaN()
+}
+
+func toString(v xvalue) string {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case []xnode:
+		if len(v) == 0 {
+			return ""
+		}
+		return v[0].stringValue()
+	}
+	return ""
+}
+
+// parseNumber parses an optionally signed decimal number surrounded by whitespace.
+// It returns NaN if s is not a number.
+func parseNumber(s string) float64 {
+	s = strings.Trim(s, " \t\r\n")
+	t := strings.TrimPrefix(s, "-")
+	digits, dot := 0, false
+	for i := 0; i < len(t); i++ {
+		switch c := t[i]; {
+		case '0' <= c && c <= '9':
+			digits++
+		case c == '.' && !dot:
+			dot = true
+		default:
+			return math.NaN()
+		}
+	}
+	if digits == 0 {
+		return math.NaN()
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		// also handles negative zero
+		return "0"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// xround rounds to the closest integer, halves are rounded towards positive infinity.
+func xround(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	if -0.5 <= f && f < 0 {
+		return math.Copysign(0, -1)
+	}
+	return math.Floor(f + 0.5)
+}
+
+// compareValues compares two values according to XPath 1.0 section 3.4.
+func compareValues(op string, l, r xvalue) bool {
+	ln, lok := l.([]xnode)
+	rn, rok := r.([]xnode)
+	switch {
+	case lok && rok:
+		for _, a := range ln {
+			as := a.stringValue()
+			for _, b := range rn {
+				if compareAtoms(op, as, b.stringValue()) {
+					return true
+				}
+			}
+		}
+		return false
+	case lok || rok:
+		ns, other, swapped := ln, r, false
+		if rok {
+			ns, other, swapped = rn, l, true
+		}
+		if b, ok := other.(bool); ok {
+			if swapped {
+				return compareAtoms(op, b, len(ns) > 0)
+			}
+			return compareAtoms(op, len(ns) > 0, b)
+		}
+		for _, n := range ns {
+			var v xvalue = n.stringValue()
+			if _, ok := other.(float64); ok {
+				v = parseNumber(v.(string))
+			}
+			if swapped && compareAtoms(op, other, v) || !swapped && compareAtoms(op, v, other) {
+				return true
+			}
+		}
+		return false
+	}
+	return compareAtoms(op, l, r)
+}
+
+// compareAtoms compares two values which are not node-sets.
+func compareAtoms(op string, l, r xvalue) bool {
+	if op == "=" || op == "!=" {
+		var eq bool
+		_, lb := l.(bool)
+		_, rb := r.(bool)
+		_, lf := l.(float64)
+		_, rf := r.(float64)
+		switch {
+		case lb || rb:
+			eq = toBoolean(l) == toBoolean(r)
+		case lf || rf:
+			eq = toNumber(l) == toNumber(r)
+		default:
+			eq = toString(l) == toString(r)
+		}
+		return eq == (op == "=")
+	}
+	a, b := toNumber(l), toNumber(r)
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	}
+	return a >= b
+}
+
+var errNodeSet = errors.New("argument is not a node-set")
+
+// xfunc is a function of the XPath core library.
+// max is -1 for an unlimited number of arguments.
+type xfunc struct {
+	min, max int
+	call     func(ctx *xcontext, args []xvalue) (xvalue, error)
+}
+
+var xfuncs = map[string]*xfunc{
+	"last": {0, 0, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return float64(ctx.size), nil
+	}},
+	"position": {0, 0, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return float64(ctx.pos), nil
+	}},
+	"count": {1, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		ns, ok := args[0].([]xnode)
+		if !ok {
+			return nil, errNodeSet
+		}
+		return float64(len(ns)), nil
+	}},
+	"id":            {1, 1, xid},
+	"local-name":    {0, 1, nodeName(localName)},
+	"namespace-uri": {0, 1, nodeName(namespaceURI)},
+	"name":          {0, 1, nodeName(qualifiedName)},
+	"string": {0, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return stringArg(ctx, args), nil
+	}},
+	"concat": {2, -1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		var b strings.Builder
+		for _, a := range args {
+			b.WriteString(toString(a))
+		}
+		return b.String(), nil
+	}},
+	"starts-with": {2, 2, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return strings.HasPrefix(toString(args[0]), toString(args[1])), nil
+	}},
+	"contains": {2, 2, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return strings.Contains(toString(args[0]), toString(args[1])), nil
+	}},
+	"substring-before": {2, 2, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		s, sep := toString(args[0]), toString(args[1])
+		if i := strings.Index(s, sep); i >= 0 {
+			return s[:i], nil
+		}
+		return "", nil
+	}},
+	"substring-after": {2, 2, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		s, sep := toString(args[0]), toString(args[1])
+		if i := strings.Index(s, sep); i >= 0 {
+			return s[i+len(sep):], nil
+		}
+		return "", nil
+	}},
+	"substring": {2, 3, xsubstring},
+	"string-length": {0, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return float64(utf8.RuneCountInString(stringArg(ctx, args))), nil
+	}},
+	"normalize-space": {0, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return strings.Join(strings.FieldsFunc(stringArg(ctx, args), func(r rune) bool {
+			return r < utf8.RuneSelf && isXMLSpace(byte(r))
+		}), " "), nil
+	}},
+	"translate": {3, 3, xtranslate},
+	"boolean": {1, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return toBoolean(args[0]), nil
+	}},
+	"not": {1, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return !toBoolean(args[0]), nil
+	}},
+	"true": {0, 0, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return true, nil
+	}},
+	"false": {0, 0, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return false, nil
+	}},
+	"lang": {1, 1, xlang},
+	"number": {0, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		if len(args) == 0 {
+			return parseNumber(ctx.node.stringValue()), nil
+		}
+		return toNumber(args[0]), nil
+	}},
+	"sum": {1, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		ns, ok := args[0].([]xnode)
+		if !ok {
+			return nil, errNodeSet
+		}
+		var sum float64
+		for _, n := range ns {
+			sum += parseNumber(n.stringValue())
+		}
+		return sum, nil
+	}},
+	"floor": {1, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return math.Floor(toNumber(args[0])), nil
+	}},
+	"ceiling": {1, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return math.Ceil(toNumber(args[0])), nil
+	}},
+	"round": {1, 1, func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		return xround(toNumber(args[0])), nil
+	}},
+}
+
+// stringArg retrieves the optional first argument as a string,
+// it defaults to the string-value of the context node.
+func stringArg(ctx *xcontext, args []xvalue) string {
+	if len(args) == 0 {
+		return ctx.node.stringValue()
+	}
+	return toString(args[0])
+}
+
+// nodeName wraps a function retrieving a name of the optional node-set argument,
+// it defaults to the context node.
+func nodeName(name func(n xnode) string) func(ctx *xcontext, args []xvalue) (xvalue, error) {
+	return func(ctx *xcontext, args []xvalue) (xvalue, error) {
+		if len(args) == 0 {
+			return name(ctx.node), nil
+		}
+		ns, ok := args[0].([]xnode)
+		if !ok {
+			return nil, errNodeSet
+		}
+		if len(ns) == 0 {
+			return "", nil
+		}
+		return name(ns[0]), nil
+	}
+}
+
+func localName(x xnode) string {
+	n := x.node()
+	if x.attr >= 0 {
+		return n.Attributes[x.attr].Key
+	}
+	if n.Type == html.ElementNode {
+		return n.Data
+	}
+	return ""
+}
+
+func namespaceURI(x xnode) string {
+	n := x.node()
+	if x.attr >= 0 {
+		if ns := n.Attributes[x.attr].Namespace; ns != "" {
+			return namespaces[ns]
+		}
+		return ""
+	}
+	if n.Type == html.ElementNode {
+		return namespaces[n.Namespace]
+	}
+	return ""
+}
+
+func qualifiedName(x xnode) string {
+	n := x.node()
+	if x.attr >= 0 {
+		a := &n.Attributes[x.attr]
+		if a.Namespace != "" {
+			return a.Namespace + ":" + a.Key
+		}
+		return a.Key
+	}
+	if n.Type == html.ElementNode && n.Namespace != "" {
+		return n.Namespace + ":" + n.Data
+	}
+	return localName(x)
+}
+
+// xid selects the elements with the whitespace separated ids.
+func xid(ctx *xcontext, args []xvalue) (xvalue, error) {
+	var ids []string
+	if ns, ok := args[0].([]xnode); ok {
+		for _, n := range ns {
+			ids = append(ids, Classes(n.stringValue())...)
+		}
+	} else {
+		ids = Classes(toString(args[0]))
+	}
+	root := xnode{path: ctx.node.path[:1:1], pos: ctx.node.pos[:1:1], attr: -1}
+	found := make(map[string]bool)
+	return appendDescendants(nil, root, func(x xnode) bool {
+		n := x.node()
+		if n.Type != html.ElementNode {
+			return false
+		}
+		id := n.Attributes.ID()
+		if id == "" || found[id] || !containsString(ids, id) {
+			return false
+		}
+		found[id] = true
+		return true
+	}), nil
+}
+
+func xsubstring(ctx *xcontext, args []xvalue) (xvalue, error) {
+	rs := []rune(toString(args[0]))
+	start := xround(toNumber(args[1]))
+	end := math.Inf(1)
+	if len(args) > 2 {
+		end = start + xround(toNumber(args[2]))
+	}
+	var b strings.Builder
+	for i, r := range rs {
+		// positions start at 1; comparisons with NaN are false
+		if p := float64(i + 1); p >= start && p < end {
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+func xtranslate(ctx *xcontext, args []xvalue) (xvalue, error) {
+	from, to := []rune(toString(args[1])), []rune(toString(args[2]))
+	return strings.Map(func(r rune) rune {
+		for i, f := range from {
+			if f != r {
+				continue
+			}
+			if i < len(to) {
+				return to[i]
+			}
+			return -1
+		}
+		return r
+	}, toString(args[0])), nil
+}
+
+// xlang reports whether the language of the context node is the argument or a sublanguage of it.
+func xlang(ctx *xcontext, args []xvalue) (xvalue, error) {
+	want := strings.ToLower(toString(args[0]))
+	for x, ok := ctx.node, true; ok; x, ok = x.parent() {
+		n := x.node()
+		if x.attr >= 0 || n.Type != html.ElementNode {
+			continue
+		}
+		lang := n.Attributes.find("lang", "xml")
+		if lang == nil {
+			lang = n.Attributes.find("lang", "")
+		}
+		if lang == nil {
+			continue
+		}
+		l := strings.ToLower(lang.Val)
+		return l == want || strings.HasPrefix(l, want+"-"), nil
+	}
+	return false, nil
+}